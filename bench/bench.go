@@ -295,6 +295,74 @@ func (bm *Benchmark) Report() string {
 	return buffer.String()
 }
 
+// SampleStats is the machine readable form of a Sample
+type SampleStats struct {
+	Msgs         int     `json:"msgs"`
+	MsgBytes     uint64  `json:"msg_bytes"`
+	MsgsPerSec   int64   `json:"msgs_per_sec"`
+	BytesPerSec  float64 `json:"bytes_per_sec"`
+	DurationSecs float64 `json:"duration_secs"`
+}
+
+// GroupStats is the machine readable form of a SampleGroup
+type GroupStats struct {
+	SampleStats
+	MinRate int64          `json:"min_rate"`
+	AvgRate int64          `json:"avg_rate"`
+	MaxRate int64          `json:"max_rate"`
+	StdDev  float64        `json:"stddev"`
+	Clients []*SampleStats `json:"clients"`
+}
+
+// Stats is the machine readable form of a Benchmark, Pubs or Subs is nil when it has no samples
+type Stats struct {
+	Name  string       `json:"name"`
+	RunID string       `json:"run_id"`
+	Total *SampleStats `json:"total,omitempty"`
+	Pubs  *GroupStats  `json:"pubs,omitempty"`
+	Subs  *GroupStats  `json:"subs,omitempty"`
+}
+
+// Stats returns the machine readable results of the Benchmark
+func (bm *Benchmark) Stats() *Stats {
+	stats := &Stats{Name: bm.Name, RunID: bm.RunID}
+	if bm.Pubs.HasSamples() && bm.Subs.HasSamples() {
+		stats.Total = bm.Sample.stats()
+	}
+	if bm.Pubs.HasSamples() {
+		stats.Pubs = bm.Pubs.stats()
+	}
+	if bm.Subs.HasSamples() {
+		stats.Subs = bm.Subs.stats()
+	}
+	return stats
+}
+
+func (s *Sample) stats() *SampleStats {
+	return &SampleStats{
+		Msgs:         s.JobMsgCnt,
+		MsgBytes:     s.MsgBytes,
+		MsgsPerSec:   s.Rate(),
+		BytesPerSec:  s.Throughput(),
+		DurationSecs: s.Seconds(),
+	}
+}
+
+func (sg *SampleGroup) stats() *GroupStats {
+	g := &GroupStats{
+		SampleStats: *sg.Sample.stats(),
+		MinRate:     sg.MinRate(),
+		AvgRate:     sg.AvgRate(),
+		MaxRate:     sg.MaxRate(),
+		StdDev:      sg.StdDev(),
+		Clients:     make([]*SampleStats, 0, len(sg.Samples)),
+	}
+	for _, s := range sg.Samples {
+		g.Clients = append(g.Clients, s.stats())
+	}
+	return g
+}
+
 func commaFormat(n int64) string {
 	in := strconv.FormatInt(n, 10)
 	out := make([]byte, len(in)+(len(in)-2+int(in[0]/'0'))/3)
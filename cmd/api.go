@@ -1,181 +1,272 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
 
 	"github.com/WuKongIM/WuKongIMCli/pkg/network"
-	"github.com/WuKongIM/WuKongIMCli/pkg/wkutil"
-	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
 	"github.com/sendgrid/rest"
+	"github.com/spf13/cobra"
 )
 
-type API struct {
-	baseURL string
-}
-
-func NewAPI() *API {
-	return &API{}
-}
-
-func (a *API) SetBaseURL(baseURL string) {
-	a.baseURL = baseURL
-}
-
-func (a *API) Route(uids []string) (map[string]string, error) {
-
-	resp, err := network.Post(a.getFullURL("/route/batch"), []byte(wkutil.ToJSON(uids)), nil)
+// newAPI 使用上下文的服务地址和凭证创建api客户端，extra中的配置优先
+func newAPI(opts *Options, extra ...wkapi.Option) (*wkapi.Client, error) {
+	headers, err := opts.APIHeaders()
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("请求失败")
+	options := []wkapi.Option{
+		wkapi.WithEndpoints(opts.Endpoints()...),
+		wkapi.WithStrategy(opts.Strategy),
+		wkapi.WithHeaders(headers),
 	}
-
-	var userAddrs []userAddrResp
-	err = wkutil.ReadJSONByByte([]byte(resp.Body), &userAddrs)
-	if err != nil {
-		return nil, err
+	if opts.ReadOnly {
+		options = append(options, wkapi.WithReadOnly())
 	}
+	return wkapi.New(append(options, extra...)...), nil
+}
 
-	resultMap := make(map[string]string)
-	if len(userAddrs) > 0 {
-		for _, userAddr := range userAddrs {
-			if len(userAddr.UIDs) > 0 {
-				for _, uid := range userAddr.UIDs {
-					resultMap[uid] = userAddr.TCPAddr
-				}
-			}
-		}
+// newAPI 使用当前上下文创建api客户端，修改数据的请求会写入审计日志和操作日志
+func (c *WuKongIMContext) newAPI(extra ...wkapi.Option) (*wkapi.Client, error) {
+	if c.audit != nil {
+		extra = append([]wkapi.Option{wkapi.WithMutationHook(c.recordMutation)}, extra...)
 	}
-	return resultMap, nil
+	return newAPI(c.opts, extra...)
+}
 
+// recordMutation 记录一次修改数据的请求
+func (c *WuKongIMContext) recordMutation(ctx context.Context, m *wkapi.Mutation) {
+	entry := c.audit.newEntry(c.journal.id, m)
+	c.audit.write(entry)
+	c.journal.record(entry)
 }
 
-func (a *API) UpdateToken(uid, token string, deviceFlag wkproto.DeviceFlag, deviceLevel wkproto.DeviceLevel) error {
+// apiMethods wk api 支持的请求方法
+var apiMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
 
-	resp, err := network.Post(a.getFullURL("/user/token"), []byte(wkutil.ToJSON(map[string]interface{}{"uid": uid, "token": token, "device_flag": deviceFlag, "device_level": deviceLevel})), nil)
-	if err != nil {
-		return err
+type apiCMD struct {
+	ctx    *WuKongIMContext
+	data   []string // 请求内容
+	dryRun bool     // 只输出请求，不发送
+}
+
+func newAPICMD(ctx *WuKongIMContext) *apiCMD {
+	return &apiCMD{
+		ctx: ctx,
 	}
-	if resp.StatusCode != http.StatusOK {
-		return a.handleError(resp)
+}
+
+func (a *apiCMD) CMD() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "api <METHOD> <path>",
+		Short: "Call any server http api with the context's server and token",
+		Example: `  wk api GET /varz
+  wk api GET /connz -d limit=10
+  wk api POST /channel/subscriber_add -d channel_id=g1 -d channel_type:=2 -d 'subscribers:=["u1","u2"]'
+  wk api POST /channel --data @channel.json`,
+		RunE: a.run,
 	}
-	return nil
+	cmd.Flags().StringArrayVarP(&a.data, "data", "d", nil, "Request data: @file (- for stdin) or raw JSON as the whole body, key=value for a string field, key:=value for a JSON field; GET sends fields as query params")
+	cmd.Flags().BoolVar(&a.dryRun, "dry-run", false, "Print the request without sending it")
+	return cmd
 }
 
-func (a *API) Varz() (*Varz, error) {
-	resp, err := network.Get(a.getFullURL("/varz"), nil, nil)
-	if err != nil {
-		return nil, err
+func (a *apiCMD) run(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		cmd.Help()
+		return nil
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("请求失败")
+	method := strings.ToUpper(args[0])
+	if !validAPIMethod(method) {
+		return fmt.Errorf("invalid method %q, must be one of %s", args[0], strings.Join(apiMethods, ", "))
 	}
-	var varz *Varz
-	err = wkutil.ReadJSONByByte([]byte(resp.Body), &varz)
+	path, queryParams, err := splitAPIPath(args[1])
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return varz, nil
-}
-
-func (a *API) CreateChannel(req *ChannelCreateReq) error {
-
-	resp, err := network.Post(a.getFullURL("/channel"), []byte(wkutil.ToJSON(req)), nil)
+	body, fields, err := parseAPIData(a.data)
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return a.handleError(resp)
+	if len(fields) > 0 {
+		if method == http.MethodGet {
+			for k, v := range fields {
+				queryParams[k] = fieldString(v)
+			}
+		} else {
+			body, err = json.Marshal(fields)
+			if err != nil {
+				return err
+			}
+		}
+	} else if method == http.MethodGet && len(body) > 0 {
+		return errors.New("GET requests can not have a body, use key=value to send query params")
+	}
+
+	if method != http.MethodGet {
+		err = a.ctx.checkMutation(cmd)
+		if err != nil {
+			return err
+		}
 	}
-	return nil
-}
 
-// SubscriberAdd 添加订阅者
-func (a *API) SubscriberAdd(req *SubscriberAddReq) error {
-	resp, err := network.Post(a.getFullURL("/channel/subscriber_add"), []byte(wkutil.ToJSON(req)), nil)
+	api, err := a.ctx.newAPI()
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return a.handleError(resp)
+	if a.dryRun {
+		var planned *plannedRequest
+		ctx := network.WithDryRun(cmd.Context(), func(request rest.Request) {
+			planned = newPlannedRequest(request)
+		})
+		_, err = api.Do(ctx, method, path, queryParams, body)
+		if err != nil {
+			return err
+		}
+		return a.ctx.out.render(planned, func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, planned)
+			return err
+		})
 	}
-	return nil
-}
-
-// SubscriberRemove 移除订阅者
-func (a *API) SubscriberRemove(req *SubscriberReq) error {
-	resp, err := network.Post(a.getFullURL("/channel/subscriber_remove"), []byte(wkutil.ToJSON(req)), nil)
+	resp, err := api.Do(cmd.Context(), method, path, queryParams, body)
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return a.handleError(resp)
-	}
-	return nil
-}
-
-// DenylistAdd 添加黑名单
-func (a *API) DenylistAdd(req *ChannelUidsReq) error {
-	resp, err := network.Post(a.getFullURL("/channel/blacklist_add"), []byte(wkutil.ToJSON(req)), nil)
+	err = a.printAPIBody(resp.Body)
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return a.handleError(resp)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return wkapi.NewAPIError(method, path, resp.StatusCode, resp.Body)
 	}
 	return nil
 }
 
-// DenylistRemove 移除黑名单
-func (a *API) DenylistRemove(req *ChannelUidsReq) error {
-	resp, err := network.Post(a.getFullURL("/channel/blacklist_remove"), []byte(wkutil.ToJSON(req)), nil)
+func validAPIMethod(method string) bool {
+	for _, m := range apiMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// splitAPIPath 拆分路径中的查询参数
+func splitAPIPath(rawPath string) (string, map[string]string, error) {
+	if strings.Contains(rawPath, "://") {
+		return "", nil, fmt.Errorf("invalid path %q, give the path only, the server comes from the context", rawPath)
+	}
+	if !strings.HasPrefix(rawPath, "/") {
+		rawPath = "/" + rawPath
+	}
+	u, err := url.Parse(rawPath)
 	if err != nil {
-		return err
+		return "", nil, fmt.Errorf("invalid path %q: %w", rawPath, err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return a.handleError(resp)
+	queryParams := make(map[string]string)
+	for k, v := range u.Query() {
+		queryParams[k] = v[len(v)-1]
 	}
-	return nil
+	return u.Path, queryParams, nil
 }
 
-func (a *API) AllowlistAdd(req *ChannelUidsReq) error {
-	resp, err := network.Post(a.getFullURL("/channel/whitelist_add"), []byte(wkutil.ToJSON(req)), nil)
-	if err != nil {
-		return err
+// parseAPIData 解析 --data，返回完整的请求内容或者字段
+func parseAPIData(data []string) ([]byte, map[string]interface{}, error) {
+	var body []byte
+	fields := make(map[string]interface{})
+	for _, d := range data {
+		switch {
+		case strings.HasPrefix(d, "@"):
+			content, err := readDataFile(d[1:])
+			if err != nil {
+				return nil, nil, err
+			}
+			body = content
+		case strings.HasPrefix(strings.TrimSpace(d), "{"), strings.HasPrefix(strings.TrimSpace(d), "["):
+			body = []byte(d)
+		default:
+			key, value, err := parseAPIField(d)
+			if err != nil {
+				return nil, nil, err
+			}
+			fields[key] = value
+		}
 	}
-	if resp.StatusCode != http.StatusOK {
-		return a.handleError(resp)
+	if body != nil && len(fields) > 0 {
+		return nil, nil, errors.New("can not mix a whole body (@file or JSON) with key=value fields")
 	}
-	return nil
+	if body != nil && !json.Valid(body) {
+		return nil, nil, errors.New("request body is not valid JSON")
+	}
+	return body, fields, nil
 }
 
-func (a *API) AllowlistRemove(req *ChannelUidsReq) error {
-	resp, err := network.Post(a.getFullURL("/channel/whitelist_remove"), []byte(wkutil.ToJSON(req)), nil)
+// parseAPIField 解析 key=value（字符串）或 key:=value（JSON）
+func parseAPIField(d string) (string, interface{}, error) {
+	i := strings.Index(d, "=")
+	if i <= 0 {
+		return "", nil, fmt.Errorf("invalid data %q, expected key=value, key:=json, @file or a JSON body", d)
+	}
+	key, value := d[:i], d[i+1:]
+	if !strings.HasSuffix(key, ":") {
+		return key, value, nil
+	}
+	key = strings.TrimSuffix(key, ":")
+	if key == "" {
+		return "", nil, fmt.Errorf("invalid data %q, missing key", d)
+	}
+	var v interface{}
+	err := json.Unmarshal([]byte(value), &v)
 	if err != nil {
-		return err
+		return "", nil, fmt.Errorf("invalid JSON value for %q: %w", key, err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return a.handleError(resp)
+	return key, v, nil
+}
+
+func readDataFile(name string) ([]byte, error) {
+	if name == "-" {
+		return ioutil.ReadAll(os.Stdin)
 	}
-	return nil
+	return ioutil.ReadFile(name)
 }
 
-func (a *API) handleError(resp *rest.Response) error {
-	if resp.StatusCode == http.StatusBadRequest {
-		resultMap, err := wkutil.JSONToMap(resp.Body)
-		if err != nil {
-			return err
-		}
-		msg, ok := resultMap["msg"]
-		if ok {
-			return errors.New(msg.(string))
-		}
+func fieldString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
 	}
-	return errors.New("未知错误")
+	data, _ := json.Marshal(v)
+	return string(data)
 }
 
-func (a *API) getFullURL(path string) string {
-	return a.baseURL + path
+// printAPIBody 输出响应内容，JSON会被格式化，非JSON的内容在json和yaml中输出为字符串
+func (a *apiCMD) printAPIBody(body string) error {
+	var v interface{}
+	if strings.TrimSpace(body) != "" {
+		v = body
+		if json.Valid([]byte(body)) {
+			v = json.RawMessage(body)
+		}
+	}
+	return a.ctx.out.render(v, func(w io.Writer) error {
+		if v == nil {
+			return nil
+		}
+		var out bytes.Buffer
+		if json.Indent(&out, []byte(body), "", "  ") != nil {
+			_, err := fmt.Fprintln(w, body)
+			return err
+		}
+		_, err := fmt.Fprintln(w, out.String())
+		return err
+	})
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitAPIPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		wantPath  string
+		wantQuery map[string]string
+		wantErr   bool
+	}{
+		{name: "path", path: "/varz", wantPath: "/varz", wantQuery: map[string]string{}},
+		{name: "leading slash added", path: "varz", wantPath: "/varz", wantQuery: map[string]string{}},
+		{name: "query", path: "/connz?limit=10&uid=u1", wantPath: "/connz", wantQuery: map[string]string{"limit": "10", "uid": "u1"}},
+		{name: "last repeated query wins", path: "/connz?limit=1&limit=2", wantPath: "/connz", wantQuery: map[string]string{"limit": "2"}},
+		{name: "full url", path: "http://127.0.0.1:5001/varz", wantErr: true},
+		{name: "invalid escape", path: "/a%zz", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, query, err := splitAPIPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitAPIPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if path != tt.wantPath || !reflect.DeepEqual(query, tt.wantQuery) {
+				t.Errorf("splitAPIPath(%q) = %q %v, want %q %v", tt.path, path, query, tt.wantPath, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestParseAPIData(t *testing.T) {
+	bodyFile := filepath.Join(t.TempDir(), "body.json")
+	if err := os.WriteFile(bodyFile, []byte(`{"uid":"u1"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		data       []string
+		wantBody   string
+		wantFields map[string]interface{}
+		wantErr    string
+	}{
+		{name: "none", wantFields: map[string]interface{}{}},
+		{name: "string fields", data: []string{"uid=u1", "token=a=b"}, wantFields: map[string]interface{}{"uid": "u1", "token": "a=b"}},
+		{name: "json fields", data: []string{"device_flag:=1", "uids:=[\"u1\",\"u2\"]", "ok:=true"}, wantFields: map[string]interface{}{"device_flag": float64(1), "uids": []interface{}{"u1", "u2"}, "ok": true}},
+		{name: "string and json fields", data: []string{"uid=u1", "level:=2"}, wantFields: map[string]interface{}{"uid": "u1", "level": float64(2)}},
+		{name: "empty value", data: []string{"uid="}, wantFields: map[string]interface{}{"uid": ""}},
+		{name: "json body", data: []string{` {"uid":"u1"}`}, wantBody: ` {"uid":"u1"}`, wantFields: map[string]interface{}{}},
+		{name: "json array body", data: []string{`["u1"]`}, wantBody: `["u1"]`, wantFields: map[string]interface{}{}},
+		{name: "file body", data: []string{"@" + bodyFile}, wantBody: `{"uid":"u1"}`, wantFields: map[string]interface{}{}},
+		{name: "body and fields", data: []string{`{"uid":"u1"}`, "token=t"}, wantErr: "can not mix"},
+		{name: "file and fields", data: []string{"token=t", "@" + bodyFile}, wantErr: "can not mix"},
+		{name: "invalid json body", data: []string{`{"uid":`}, wantErr: "not valid JSON"},
+		{name: "invalid json field", data: []string{"uids:=[u1]"}, wantErr: `invalid JSON value for "uids"`},
+		{name: "missing key", data: []string{":=1"}, wantErr: "missing key"},
+		{name: "no separator", data: []string{"uid"}, wantErr: "expected key=value"},
+		{name: "no key", data: []string{"=u1"}, wantErr: "expected key=value"},
+		{name: "missing file", data: []string{"@" + bodyFile + ".missing"}, wantErr: "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, fields, err := parseAPIData(tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseAPIData(%q) error = %v, want %q", tt.data, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAPIData(%q) error = %v", tt.data, err)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("parseAPIData(%q) body = %s, want %s", tt.data, body, tt.wantBody)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("parseAPIData(%q) fields = %v, want %v", tt.data, fields, tt.wantFields)
+			}
+		})
+	}
+}
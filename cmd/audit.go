@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
+	"github.com/spf13/cobra"
+)
+
+// noContextAudit 没有使用上下文（只指定了 --server）时的审计日志名称
+const noContextAudit = "_default"
+
+// auditEntry 审计日志中的一条记录，对应一次修改数据的请求
+type auditEntry struct {
+	Time        time.Time `json:"time"`
+	RunID       string    `json:"run_id"`  // 执行ID，wk undo 使用
+	User        string    `json:"user"`    // 执行命令的系统用户
+	Context     string    `json:"context"` // 上下文名称
+	Command     string    `json:"command"` // 命令行，token等参数会被打码
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	ChannelId   string    `json:"channel_id,omitempty"`
+	ChannelType uint8     `json:"channel_type,omitempty"`
+	Uids        []string  `json:"uids,omitempty"`  // 请求中的用户（uid、uids、subscribers）
+	Reset       bool      `json:"reset,omitempty"` // 添加订阅者时重置了原来的订阅者
+	OK          bool      `json:"ok"`
+	Status      int       `json:"status,omitempty"` // http状态码，没有响应时为0
+	Error       string    `json:"error,omitempty"`
+	LatencyMs   int64     `json:"latency_ms"`
+}
+
+// auditLog 追加写入上下文的审计日志（~/wukongim/.config/audit/<上下文>.jsonl）
+type auditLog struct {
+	path    string
+	context string
+	user    string
+	command string
+
+	mu     sync.Mutex
+	file   *os.File
+	failed bool // 写入失败后不再重试，只提示一次
+}
+
+func newAuditLog(contextName string, args []string) *auditLog {
+	name := contextName
+	if name == "" {
+		name = noContextAudit
+	}
+	return &auditLog{
+		path:    auditPath(name),
+		context: contextName,
+		user:    osUser(),
+		command: strings.Join(redactArgs(args), " "),
+	}
+}
+
+func auditPath(name string) string {
+	return filepath.Join(filepath.Dir(NewOptions().ContextDir()), "audit", name+".jsonl")
+}
+
+func osUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
+	}
+	return u.Username
+}
+
+// redactFlags 值需要打码的参数
+var redactFlags = []string{"--token", "--header", "--user-token"}
+
+// redactArgs 打码命令行中的token和请求头
+func redactArgs(args []string) []string {
+	redactedArgs := make([]string, len(args))
+	copy(redactedArgs, args)
+	for i := 0; i < len(redactedArgs); i++ {
+		for _, flag := range redactFlags {
+			if redactedArgs[i] == flag && i+1 < len(redactedArgs) {
+				i++
+				redactedArgs[i] = redactFlagValue(flag, redactedArgs[i])
+				break
+			}
+			if strings.HasPrefix(redactedArgs[i], flag+"=") {
+				redactedArgs[i] = flag + "=" + redactFlagValue(flag, strings.TrimPrefix(redactedArgs[i], flag+"="))
+				break
+			}
+		}
+	}
+	return redactedArgs
+}
+
+// redactFlagValue 请求头只打码值，保留名称
+func redactFlagValue(flag, value string) string {
+	if flag == "--header" {
+		if name, _, ok := strings.Cut(value, "="); ok {
+			return name + "=***"
+		}
+	}
+	return "***"
+}
+
+// newEntry 修改数据的请求对应的记录
+func (a *auditLog) newEntry(runID string, m *wkapi.Mutation) *auditEntry {
+	entry := &auditEntry{
+		Time:      time.Now(),
+		RunID:     runID,
+		User:      a.user,
+		Context:   a.context,
+		Command:   a.command,
+		Method:    m.Method,
+		Path:      m.Path,
+		OK:        m.Err == nil,
+		Status:    m.StatusCode,
+		LatencyMs: m.Latency.Milliseconds(),
+	}
+	if m.Err != nil {
+		entry.Error = m.Err.Error()
+	}
+	entry.summarize(m.Body)
+	return entry
+}
+
+// write 追加一条记录，写入失败不影响命令执行
+func (a *auditLog) write(entry *auditEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failed {
+		return
+	}
+	if a.file == nil {
+		err = os.MkdirAll(filepath.Dir(a.path), 0700)
+		if err == nil {
+			a.file, err = os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		}
+	}
+	if err == nil {
+		_, err = a.file.Write(append(data, '\n'))
+	}
+	if err != nil {
+		a.failed = true
+		log.Printf("write audit log %s: %v", a.path, err)
+	}
+}
+
+// summarize 从请求内容中提取频道和用户，不记录完整的请求内容（可能包含用户token）
+func (e *auditEntry) summarize(body []byte) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return
+	}
+	json.Unmarshal(fields["channel_id"], &e.ChannelId)
+	json.Unmarshal(fields["channel_type"], &e.ChannelType)
+	var reset int
+	if json.Unmarshal(fields["reset"], &reset) == nil && reset == 1 {
+		e.Reset = true
+	}
+	var uid string
+	if json.Unmarshal(fields["uid"], &uid) == nil && uid != "" {
+		e.Uids = append(e.Uids, uid)
+	}
+	for _, key := range []string{"uids", "subscribers"} {
+		var uids []string
+		if json.Unmarshal(fields[key], &uids) == nil {
+			e.Uids = append(e.Uids, uids...)
+		}
+	}
+}
+
+type auditCMD struct {
+	ctx     *WuKongIMContext
+	since   string
+	until   string
+	channel string
+	uid     string
+	limit   int
+}
+
+func newAuditCMD(ctx *WuKongIMContext) *auditCMD {
+	return &auditCMD{
+		ctx: ctx,
+	}
+}
+
+func (a *auditCMD) CMD() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Query the audit log of changes made through the current context",
+		Example: `  wk audit --since 24h
+  wk audit --channel grp1 --since 2024-01-02
+  wk audit --uid usr1 -o json`,
+		RunE: a.run,
+	}
+	cmd.Flags().StringVar(&a.since, "since", "", "Only entries at or after this time: a duration (24h), a date (2006-01-02) or RFC3339")
+	cmd.Flags().StringVar(&a.until, "until", "", "Only entries before this time, same formats as --since")
+	cmd.Flags().StringVar(&a.channel, "channel", "", "Only entries for this channel ID")
+	cmd.Flags().StringVar(&a.uid, "uid", "", "Only entries that include this UID")
+	cmd.Flags().IntVar(&a.limit, "limit", 100, "Show at most this many of the latest entries, 0 for all")
+	return cmd
+}
+
+func (a *auditCMD) run(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+	since, err := parseAuditTime(a.since, now)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	until, err := parseAuditTime(a.until, now)
+	if err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+	name := a.ctx.contextName
+	if name == "" {
+		name = noContextAudit
+	}
+	entries, err := a.query(auditPath(name), since, until)
+	if err != nil {
+		return err
+	}
+	return a.ctx.out.render(entries, func(out io.Writer) error {
+		if len(entries) == 0 {
+			fmt.Fprintln(out, "No audit entries")
+			return nil
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "TIME\tRUN\tUSER\tREQUEST\tCHANNEL\tUIDS\tRESULT\tCOMMAND")
+		for _, e := range entries {
+			result := "ok"
+			if !e.OK {
+				result = "failed"
+				if e.Status > 0 {
+					result = fmt.Sprintf("failed (%d)", e.Status)
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s %s\t%s\t%s\t%s\t%s\n", e.Time.Local().Format("2006-01-02 15:04:05"), e.RunID, e.User, e.Method, e.Path, e.ChannelId, summarizeUids(e.Uids), result, e.Command)
+		}
+		return w.Flush()
+	})
+}
+
+// query 读取审计日志中符合条件的记录，最多返回最新的limit条
+func (a *auditCMD) query(path string, since, until time.Time) ([]*auditEntry, error) {
+	entries := make([]*auditEntry, 0)
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		entry := &auditEntry{}
+		if json.Unmarshal(scanner.Bytes(), entry) != nil {
+			continue // 写入中断的行
+		}
+		if !a.match(entry, since, until) {
+			continue
+		}
+		entries = append(entries, entry)
+		if a.limit > 0 && len(entries) > a.limit {
+			entries = entries[1:]
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func (a *auditCMD) match(e *auditEntry, since, until time.Time) bool {
+	if !since.IsZero() && e.Time.Before(since) {
+		return false
+	}
+	if !until.IsZero() && !e.Time.Before(until) {
+		return false
+	}
+	if a.channel != "" && e.ChannelId != a.channel {
+		return false
+	}
+	if a.uid != "" {
+		for _, uid := range e.Uids {
+			if uid == a.uid {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// parseAuditTime 解析时间：距现在的时长（24h）、日期（2006-01-02，本地时间）或RFC3339
+func parseAuditTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a duration (24h), date (2006-01-02) or RFC3339 time", value)
+	}
+	return t, nil
+}
+
+// summarizeUids 表格中最多显示3个用户
+func summarizeUids(uids []string) string {
+	if len(uids) <= 3 {
+		return strings.Join(uids, ",")
+	}
+	return fmt.Sprintf("%s (+%d)", strings.Join(uids[:3], ","), len(uids)-3)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedactArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"no secrets", []string{"wk", "subscriber", "add", "--chNum", "10"}, []string{"wk", "subscriber", "add", "--chNum", "10"}},
+		{"token", []string{"wk", "--token", "secret", "doctor"}, []string{"wk", "--token", "***", "doctor"}},
+		{"token with =", []string{"wk", "--token=secret", "doctor"}, []string{"wk", "--token=***", "doctor"}},
+		{"header keeps the name", []string{"wk", "context", "add", "--header", "X-Auth=secret"}, []string{"wk", "context", "add", "--header", "X-Auth=***"}},
+		{"header with =", []string{"wk", "context", "add", "--header=X-Auth=secret"}, []string{"wk", "context", "add", "--header=X-Auth=***"}},
+		{"header without value", []string{"wk", "--header", "secret"}, []string{"wk", "--header", "***"}},
+		{"user token", []string{"wk", "user", "token", "set", "u1", "--user-token", "secret"}, []string{"wk", "user", "token", "set", "u1", "--user-token", "***"}},
+		{"user token with =", []string{"wk", "user", "token", "set", "u1", "--user-token=secret"}, []string{"wk", "user", "token", "set", "u1", "--user-token=***"}},
+		{"several secrets", []string{"wk", "--token", "a", "--user-token=b", "--header", "X=c"}, []string{"wk", "--token", "***", "--user-token=***", "--header", "X=***"}},
+		{"value that looks like a flag", []string{"wk", "--token", "--token", "x"}, []string{"wk", "--token", "***", "x"}},
+		{"flag at the end", []string{"wk", "doctor", "--token"}, []string{"wk", "doctor", "--token"}},
+		{"similar flag kept", []string{"wk", "--token-mode", "random"}, []string{"wk", "--token-mode", "random"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string(nil), tt.args...)
+			got := redactArgs(args)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("redactArgs(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("redactArgs modified its input: %q", args)
+			}
+		})
+	}
+}
+
+// redactFlags 中的每个参数的值都不会出现在结果中
+func TestRedactArgsCoversRedactFlags(t *testing.T) {
+	for _, flag := range redactFlags {
+		got := strings.Join(redactArgs([]string{"wk", flag, "X=secret", flag + "=X=secret"}), " ")
+		if strings.Contains(got, "secret") {
+			t.Errorf("redactArgs leaks the value of %s: %s", flag, got)
+		}
+	}
+}
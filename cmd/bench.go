@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"strconv"
 	"sync"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/WuKongIM/WuKongIM/pkg/client"
 	"github.com/WuKongIM/WuKongIMCli/bench"
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
 	wkproto "github.com/WuKongIM/WuKongIMGoProto"
 	"github.com/dustin/go-humanize"
 	"github.com/gosuri/uiprogress"
@@ -21,6 +23,8 @@ type benchCMD struct {
 	channelNum  int // 频道数量
 	pub         int
 	sub         int
+	pubUids     string // 发送者ID来源，指定后忽略pub
+	subUids     string // 接收者ID来源，指定后忽略sub
 	msgs        int
 	msgSize     int
 	ctx         *WuKongIMContext
@@ -31,13 +35,13 @@ type benchCMD struct {
 	p2p         bool   // 是否是点对点聊天
 	fromUID     string // 如果是p2p模式 则对应的发送者
 	toUID       string // 如果是p2p模式 则对应的接受者
-	api         *API
+	api         wkapi.Interface
+	bars        *uiprogress.Progress // 进度条，非交互输出时不显示
 }
 
 func newBenchCMD(ctx *WuKongIMContext) *benchCMD {
 	b := &benchCMD{
 		ctx: ctx,
-		api: NewAPI(),
 	}
 	return b
 }
@@ -49,7 +53,7 @@ func (b *benchCMD) CMD() *cobra.Command {
 		RunE:  b.run,
 	}
 	b.initVar(cmd)
-	return cmd
+	return mutating(cmd)
 }
 
 func (b *benchCMD) initVar(cmd *cobra.Command) {
@@ -59,14 +63,20 @@ func (b *benchCMD) initVar(cmd *cobra.Command) {
 	cmd.Flags().IntVar(&b.msgSize, "size", 128, "Size of the test messages,unit byte（测试消息大小,单位byte）")
 	cmd.Flags().BoolVar(&b.noProgress, "no-progress", false, "Disable progress bar while publishing（不显示进度条）")
 	cmd.Flags().DurationVar(&b.pubSleep, "pubsleep", 0, "Sleep for the specified interval after publishing each message（每条消息发送间隔）")
-	cmd.Flags().StringArrayVar(&b.channels, "channels", []string{}, "channel list（接受消息的频道集合）")
+	cmd.Flags().StringArrayVar(&b.channels, "channels", []string{}, "channel list, "+idSourceUsage+"（接受消息的频道集合）")
 	cmd.Flags().Uint8Var(&b.channelType, "channelType", 6, "channel type（频道类型）")
 	cmd.Flags().IntVar(&b.channelNum, "channelNum", 1, "channel number（频道数量）")
+	cmd.Flags().StringVar(&b.pubUids, "pub-uids", "", "sender uids, "+idSourceUsage+"（发送者，指定后忽略--pub）")
+	cmd.Flags().StringVar(&b.subUids, "sub-uids", "", "receiver uids, "+idSourceUsage+"（接受者，指定后忽略--sub）")
 
 }
 
 func (b *benchCMD) run(cmd *cobra.Command, args []string) error {
-	b.api.SetBaseURL(b.ctx.opts.ServerAddr)
+	api, err := b.ctx.newAPI()
+	if err != nil {
+		return err
+	}
+	b.api = api
 
 	if b.channelType == 0 {
 		b.channelType = 6
@@ -74,7 +84,11 @@ func (b *benchCMD) run(cmd *cobra.Command, args []string) error {
 
 	// ========== 初始化频道 ==========
 	if len(b.channels) > 0 {
-		for _, channel := range b.channels {
+		channels, err := expandIDs(b.channels)
+		if err != nil {
+			return err
+		}
+		for _, channel := range channels {
 			b.channelList = append(b.channelList, client.NewChannel(channel, b.channelType))
 		}
 	}
@@ -88,18 +102,34 @@ func (b *benchCMD) run(cmd *cobra.Command, args []string) error {
 	userPrefix := strconv.FormatInt(time.Now().UnixMilli(), 16) // 客户端前缀
 	publishers := []string{}                                    // 发布者
 	subscribers := []string{}                                   // 订阅者
-	for i := 0; i < b.pub; i++ {
-		uid := fmt.Sprintf("%s-%d", userPrefix, i)
-		publishers = append(publishers, uid)
+	if b.pubUids != "" {
+		publishers, err = parseIDs(b.pubUids)
+		if err != nil {
+			return err
+		}
+		b.pub = len(publishers)
+	} else {
+		for i := 0; i < b.pub; i++ {
+			uid := fmt.Sprintf("%s-%d", userPrefix, i)
+			publishers = append(publishers, uid)
+		}
 	}
 
-	for i := 0; i < b.sub; i++ {
-		uid := fmt.Sprintf("%s-%d", userPrefix, i)
-		subscribers = append(subscribers, uid)
+	if b.subUids != "" {
+		subscribers, err = parseIDs(b.subUids)
+		if err != nil {
+			return err
+		}
+		b.sub = len(subscribers)
+	} else {
+		for i := 0; i < b.sub; i++ {
+			uid := fmt.Sprintf("%s-%d", userPrefix, i)
+			subscribers = append(subscribers, uid)
+		}
 	}
 
 	// ========== 获取用户的长连接地址 ==========
-	userTcpAddrMap, err := b.api.Route(append(publishers, subscribers...))
+	userTcpAddrMap, err := b.api.Route(cmd.Context(), append(publishers, subscribers...))
 	if err != nil {
 		panic(err)
 	}
@@ -125,9 +155,10 @@ func (b *benchCMD) run(cmd *cobra.Command, args []string) error {
 
 	clientNum := len(pubClients) + len(subClients)
 
-	uiprogress.Start()
+	b.bars = b.ctx.out.progress()
+	b.bars.Start()
 
-	progress := uiprogress.AddBar(clientNum).AppendCompleted().PrependElapsed()
+	progress := b.bars.AddBar(clientNum).AppendCompleted().PrependElapsed()
 	state := "Connecting"
 	progress.PrependFunc(func(b *uiprogress.Bar) string {
 		return state
@@ -174,12 +205,12 @@ func (b *benchCMD) run(cmd *cobra.Command, args []string) error {
 	donewg.Wait()
 	bm.Close()
 
-	uiprogress.Stop()
-
-	fmt.Println()
+	b.bars.Stop()
 
-	fmt.Println(bm.Report())
-	return nil
+	return b.ctx.out.render(bm.Stats(), func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "\n%s\n", bm.Report())
+		return err
+	})
 }
 
 func (b *benchCMD) runReceiver(bm *bench.Benchmark, cli *client.Client, startwg *sync.WaitGroup, donewg *sync.WaitGroup, numMsg int) {
@@ -192,7 +223,7 @@ func (b *benchCMD) runReceiver(bm *bench.Benchmark, cli *client.Client, startwg
 	log.Printf("Starting receiver, expecting %s messages", humanize.Comma(int64(numMsg)))
 
 	if !b.noProgress {
-		progress = uiprogress.AddBar(numMsg).AppendCompleted().PrependElapsed()
+		progress = b.bars.AddBar(numMsg).AppendCompleted().PrependElapsed()
 		progress.Width = progressWidth()
 	}
 	state := "Setup     "
@@ -238,7 +269,7 @@ func (b *benchCMD) runSender(bm *bench.Benchmark, cli *client.Client, startwg *s
 	log.Printf("Starting pub, sending %s messages", humanize.Comma(int64(numMsg)))
 
 	if !b.noProgress {
-		progress = uiprogress.AddBar(numMsg).AppendCompleted().PrependElapsed()
+		progress = b.bars.AddBar(numMsg).AppendCompleted().PrependElapsed()
 		progress.Width = progressWidth()
 	}
 	var msg []byte
@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/network"
+	"github.com/gosuri/uiprogress"
+	"github.com/sendgrid/rest"
+	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+)
+
+// bulkVar 批量命令的公共参数
+type bulkVar struct {
+	concurrency     int     // 同时进行的请求数
+	rps             float64 // 每秒最多请求数，0表示不限制
+	continueOnError bool    // 失败后继续执行剩余的操作
+	report          string  // 未完成操作的记录文件
+	resume          string  // 只执行记录文件中未完成的操作
+	dryRun          bool    // 只输出要发送的请求
+	confirmVar
+}
+
+func (b *bulkVar) initFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&b.concurrency, "concurrency", 10, "Number of requests in flight at once")
+	cmd.Flags().Float64Var(&b.rps, "rps", 0, "Max requests per second, 0 means unlimited")
+	cmd.Flags().BoolVar(&b.continueOnError, "continue-on-error", false, "Keep going after a failed request instead of stopping")
+	cmd.Flags().StringVar(&b.report, "report", "", "File to write failed and skipped items to (default ~/wukongim/.config/checkpoints/<command>-<time>.json)")
+	cmd.Flags().StringVar(&b.resume, "resume", "", "Checkpoint written by a previous run; only its failed and skipped items are run again")
+	cmd.Flags().BoolVar(&b.dryRun, "dry-run", false, "Print the requests and how many channels and uids they affect without sending anything")
+	b.confirmVar.initFlags(cmd)
+}
+
+func (b *bulkVar) check() error {
+	if b.concurrency <= 0 {
+		return fmt.Errorf("invalid --concurrency %d, must be greater than 0", b.concurrency)
+	}
+	if b.rps < 0 {
+		return fmt.Errorf("invalid --rps %g, must not be negative", b.rps)
+	}
+	return b.confirmVar.check()
+}
+
+// bulkTask 批量命令中的一个操作
+type bulkTask struct {
+	name      string   // 操作的对象（比如频道ID），用于输出错误
+	channelId string   // 影响的频道
+	uids      []string // 影响的用户（频道不为空时为频道中的成员）
+	run       func(ctx context.Context) error
+
+	index int // 在本次命令所有操作中的序号，--resume 按序号找到操作（name可能重复）
+}
+
+// bulkImpact 批量命令影响的范围
+type bulkImpact struct {
+	Channels    int `json:"channels"`
+	Uids        int `json:"uids"`
+	Memberships int `json:"memberships,omitempty"` // 频道成员关系的数量
+}
+
+func newBulkImpact(tasks []*bulkTask) *bulkImpact {
+	impact := &bulkImpact{}
+	channels := make(map[string]struct{})
+	uids := make(map[string]struct{})
+	for _, task := range tasks {
+		if task.channelId != "" {
+			channels[task.channelId] = struct{}{}
+			impact.Memberships += len(task.uids)
+		}
+		for _, uid := range task.uids {
+			uids[uid] = struct{}{}
+		}
+	}
+	impact.Channels = len(channels)
+	impact.Uids = len(uids)
+	return impact
+}
+
+// size 影响的数量：有成员关系时为成员关系的数量，否则为频道和用户的数量
+func (i *bulkImpact) size() int {
+	if i.Memberships > 0 {
+		return i.Memberships
+	}
+	return i.Channels + i.Uids
+}
+
+func (i *bulkImpact) String() string {
+	s := fmt.Sprintf("%d channels and %d uids", i.Channels, i.Uids)
+	if i.Memberships > 0 {
+		s += fmt.Sprintf(" (%d memberships)", i.Memberships)
+	}
+	return s
+}
+
+// bulkPlan --dry-run 的结果
+type bulkPlan struct {
+	Command  string            `json:"command"`
+	Target   string            `json:"target"`
+	Requests []*plannedRequest `json:"requests"`
+	Impact   *bulkImpact       `json:"impact"`
+}
+
+// bulkResult 批量命令的执行结果
+type bulkResult struct {
+	Command   string  `json:"command"`
+	Total     int     `json:"total"`
+	Succeeded int     `json:"succeeded"`
+	Failed    int     `json:"failed"`
+	Skipped   int     `json:"skipped"` // 出错或取消后未执行的操作数量
+	ElapsedMs int64   `json:"elapsed_ms"`
+	RPS       float64 `json:"rps"`               // 实际每秒完成的请求数
+	Resumed   string  `json:"resumed,omitempty"` // 继续执行的记录文件
+	Report    string  `json:"report,omitempty"`  // 未完成操作的记录文件
+}
+
+// 操作的状态
+const (
+	bulkFailed  = "failed"
+	bulkSkipped = "skipped"
+)
+
+// bulkItem 未完成的操作
+type bulkItem struct {
+	Index  int    `json:"index"` // 在命令所有操作中的序号
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// bulkCheckpoint 记录一次批量命令中未完成的操作，用于 --resume
+type bulkCheckpoint struct {
+	Command   string      `json:"command"`
+	CreatedAt time.Time   `json:"created_at"`
+	Total     int         `json:"total"` // 命令所有操作的数量，--resume 时需要相同
+	Items     []*bulkItem `json:"items"`
+}
+
+// bulkExecutor 并发执行批量操作，显示进度条，结束后输出执行结果
+// 某个操作失败后不再执行剩余的操作（--continue-on-error 时继续），已经开始的操作会执行完
+type bulkExecutor struct {
+	ctx     *WuKongIMContext
+	bulkVar *bulkVar
+	result  *bulkResult
+	bar     *uiprogress.Bar
+	state   atomic.Value // 进度条前显示的状态（string），进度条在另一个goroutine中读取
+	total   int          // 命令所有操作的数量（--resume 时不只是本次执行的操作）
+
+	mu       sync.Mutex
+	firstErr error
+	items    []*bulkItem // 与tasks一一对应，成功的操作为nil
+}
+
+// runBulk 按 --concurrency 和 --rps 执行tasks
+func (c *WuKongIMContext) runBulk(cmd *cobra.Command, v *bulkVar, tasks []*bulkTask) error {
+	err := v.check()
+	if err != nil {
+		return err
+	}
+	e := &bulkExecutor{
+		ctx:     c,
+		bulkVar: v,
+		result: &bulkResult{
+			Command: commandKey(cmd),
+		},
+	}
+	e.state.Store("Setup     ")
+	for i, task := range tasks {
+		task.index = i
+	}
+	e.total = len(tasks)
+	if v.resume != "" {
+		tasks, err = e.resumeTasks(tasks)
+		if err != nil {
+			return err
+		}
+	}
+	impact := newBulkImpact(tasks)
+	if v.dryRun {
+		return c.dryRunBulk(cmd, tasks, impact)
+	}
+	err = c.confirmImpact(e.result.Command, &v.confirmVar, impact)
+	if err != nil {
+		return err
+	}
+	e.result.Total = len(tasks)
+	e.items = make([]*bulkItem, len(tasks))
+	return e.run(cmd.Context(), tasks)
+}
+
+// dryRunBulk 依次执行tasks但不发送请求，输出请求和影响的范围
+func (c *WuKongIMContext) dryRunBulk(cmd *cobra.Command, tasks []*bulkTask, impact *bulkImpact) error {
+	plan := &bulkPlan{
+		Command:  commandKey(cmd),
+		Target:   c.target(),
+		Requests: make([]*plannedRequest, 0, len(tasks)),
+		Impact:   impact,
+	}
+	ctx := network.WithDryRun(cmd.Context(), func(request rest.Request) {
+		plan.Requests = append(plan.Requests, newPlannedRequest(request))
+	})
+	for _, task := range tasks {
+		err := task.run(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", task.name, err)
+		}
+	}
+	return c.out.render(plan, func(w io.Writer) error {
+		for _, request := range plan.Requests {
+			fmt.Fprintln(w, request)
+		}
+		_, err := fmt.Fprintf(w, "Dry run: %s would send %d requests affecting %s on %s, nothing was sent\n", plan.Command, len(plan.Requests), plan.Impact, plan.Target)
+		return err
+	})
+}
+
+// resumeTasks 只保留记录文件中未完成的操作
+func (e *bulkExecutor) resumeTasks(tasks []*bulkTask) ([]*bulkTask, error) {
+	cp, err := loadBulkCheckpoint(e.bulkVar.resume)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Command != e.result.Command {
+		return nil, fmt.Errorf("checkpoint %s was written by %q, not %q", e.bulkVar.resume, cp.Command, e.result.Command)
+	}
+	if cp.Total != len(tasks) {
+		return nil, fmt.Errorf("checkpoint %s was written for %d items, this run has %d, resume with the same flags as the original run", e.bulkVar.resume, cp.Total, len(tasks))
+	}
+	outstanding := make([]*bulkTask, 0, len(cp.Items))
+	seen := make(map[int]bool, len(cp.Items))
+	for _, item := range cp.Items {
+		if item.Index < 0 || item.Index >= len(tasks) || tasks[item.Index].name != item.Name {
+			return nil, fmt.Errorf("checkpoint item %d %q is not part of this run, resume with the same flags as the original run", item.Index, item.Name)
+		}
+		if seen[item.Index] {
+			continue
+		}
+		seen[item.Index] = true
+		outstanding = append(outstanding, tasks[item.Index])
+	}
+	e.result.Resumed = e.bulkVar.resume
+	return outstanding, nil
+}
+
+func (e *bulkExecutor) run(ctx context.Context, tasks []*bulkTask) error {
+	progress := e.ctx.out.progress()
+	progress.Start()
+	e.bar = progress.AddBar(max(len(tasks), 1)).AppendCompleted().PrependElapsed()
+	e.bar.Width = progressWidth()
+	e.bar.PrependFunc(func(b *uiprogress.Bar) string {
+		state, _ := e.state.Load().(string)
+		return state
+	})
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var limiter *rate.Limiter
+	if e.bulkVar.rps > 0 {
+		limiter = rate.NewLimiter(rate.Limit(e.bulkVar.rps), 1)
+	}
+
+	start := time.Now()
+	e.state.Store("Running   ")
+	taskCh := make(chan int)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < e.bulkVar.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range taskCh {
+				task := tasks[idx]
+				e.done(ctx, idx, task, task.run(ctx), cancel)
+			}
+		}()
+	}
+	dispatched := 0
+	for idx := range tasks {
+		if ctx.Err() != nil {
+			break
+		}
+		if limiter != nil && limiter.Wait(ctx) != nil {
+			break
+		}
+		select {
+		case taskCh <- idx:
+			dispatched++
+		case <-ctx.Done():
+		}
+	}
+	close(taskCh)
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	e.state.Store("Finished  ")
+	progress.Stop()
+
+	for idx := dispatched; idx < len(tasks); idx++ {
+		e.items[idx] = &bulkItem{Index: tasks[idx].index, Name: tasks[idx].name, Status: bulkSkipped}
+	}
+	e.result.Skipped += len(tasks) - dispatched
+	e.result.ElapsedMs = elapsed.Milliseconds()
+	if elapsed > 0 {
+		e.result.RPS = float64(e.result.Succeeded+e.result.Failed) / elapsed.Seconds()
+	}
+	err := e.writeReport(e.total)
+	if err != nil {
+		return err
+	}
+	err = e.ctx.out.render(e.result, e.printSummary)
+	if err != nil {
+		return err
+	}
+	if e.firstErr != nil {
+		if e.result.Failed > 1 {
+			return fmt.Errorf("%d of %d failed, first error: %w", e.result.Failed, e.result.Total, e.firstErr)
+		}
+		return e.firstErr
+	}
+	return ctx.Err() // 被中断（Ctrl+C）时返回取消的错误
+}
+
+// done 记录一个操作的结果，操作失败时取消剩余的操作
+func (e *bulkExecutor) done(ctx context.Context, idx int, task *bulkTask, err error, cancel context.CancelFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err == nil {
+		e.result.Succeeded++
+		e.bar.Incr()
+		return
+	}
+	if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		// 因为前面的错误或者Ctrl+C被取消，不算作失败
+		e.result.Skipped++
+		e.items[idx] = &bulkItem{Index: task.index, Name: task.name, Status: bulkSkipped}
+		return
+	}
+	e.result.Failed++
+	e.items[idx] = &bulkItem{Index: task.index, Name: task.name, Status: bulkFailed, Error: err.Error()}
+	if e.bulkVar.continueOnError {
+		e.bar.Incr()
+	}
+	if e.firstErr == nil {
+		e.firstErr = fmt.Errorf("%s: %w", task.name, err)
+		if !e.bulkVar.continueOnError {
+			cancel()
+		}
+	}
+}
+
+// writeReport 有未完成的操作时写入记录文件
+func (e *bulkExecutor) writeReport(total int) error {
+	cp := &bulkCheckpoint{
+		Command:   e.result.Command,
+		CreatedAt: time.Now(),
+		Total:     total,
+	}
+	for _, item := range e.items {
+		if item != nil {
+			cp.Items = append(cp.Items, item)
+		}
+	}
+	if len(cp.Items) == 0 {
+		return nil
+	}
+	path := e.bulkVar.report
+	if path == "" {
+		path = filepath.Join(checkpointDir(), fmt.Sprintf("%s-%s.json", strings.ReplaceAll(cp.Command, " ", "-"), cp.CreatedAt.Format("20060102-150405")))
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path, data, 0600)
+	if err != nil {
+		return fmt.Errorf("write report %s: %w", path, err)
+	}
+	e.result.Report = path
+	return nil
+}
+
+// checkpointDir 默认的记录文件目录（~/wukongim/.config/checkpoints）
+func checkpointDir() string {
+	return filepath.Join(filepath.Dir(NewOptions().ContextDir()), "checkpoints")
+}
+
+func loadBulkCheckpoint(path string) (*bulkCheckpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cp := &bulkCheckpoint{}
+	err = json.Unmarshal(data, cp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	return cp, nil
+}
+
+func (e *bulkExecutor) printSummary(w io.Writer) error {
+	r := e.result
+	_, err := fmt.Fprintf(w, "%s: %d/%d succeeded, %d failed, %d skipped in %s (%.1f req/s)\n", r.Command, r.Succeeded, r.Total, r.Failed, r.Skipped, time.Duration(r.ElapsedMs)*time.Millisecond, r.RPS)
+	if err != nil {
+		return err
+	}
+	if r.Report != "" {
+		_, err = fmt.Fprintf(w, "Failed and skipped items were written to %s, run the same command with --resume %s to retry them\n", r.Report, r.Report)
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResumeTasks(t *testing.T) {
+	// 同一个频道上的多个操作名称相同（比如 wk undo）
+	names := []string{"subscriber remove g1", "subscriber remove g1", "subscriber remove g2", "subscriber remove g1"}
+	newTasks := func() []*bulkTask {
+		tasks := make([]*bulkTask, 0, len(names))
+		for i, name := range names {
+			tasks = append(tasks, &bulkTask{name: name, index: i})
+		}
+		return tasks
+	}
+
+	tests := []struct {
+		name    string
+		cp      *bulkCheckpoint
+		want    []int
+		wantErr string
+	}{
+		{
+			name: "duplicate names resumed by index",
+			cp: &bulkCheckpoint{Command: "undo", Total: 4, Items: []*bulkItem{
+				{Index: 1, Name: "subscriber remove g1", Status: bulkFailed},
+				{Index: 3, Name: "subscriber remove g1", Status: bulkSkipped},
+			}},
+			want: []int{1, 3},
+		},
+		{
+			name: "repeated item only once",
+			cp: &bulkCheckpoint{Command: "undo", Total: 4, Items: []*bulkItem{
+				{Index: 2, Name: "subscriber remove g2", Status: bulkFailed},
+				{Index: 2, Name: "subscriber remove g2", Status: bulkFailed},
+			}},
+			want: []int{2},
+		},
+		{
+			name:    "other command",
+			cp:      &bulkCheckpoint{Command: "subscriber add", Total: 4},
+			wantErr: "was written by",
+		},
+		{
+			name:    "different number of tasks",
+			cp:      &bulkCheckpoint{Command: "undo", Total: 5},
+			wantErr: "written for 5 items",
+		},
+		{
+			name: "name does not match index",
+			cp: &bulkCheckpoint{Command: "undo", Total: 4, Items: []*bulkItem{
+				{Index: 0, Name: "subscriber remove g2", Status: bulkFailed},
+			}},
+			wantErr: "not part of this run",
+		},
+		{
+			name: "index out of range",
+			cp: &bulkCheckpoint{Command: "undo", Total: 4, Items: []*bulkItem{
+				{Index: 4, Name: "subscriber remove g1", Status: bulkFailed},
+			}},
+			wantErr: "not part of this run",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cp.json")
+			data, err := json.Marshal(tt.cp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+			e := &bulkExecutor{bulkVar: &bulkVar{resume: path}, result: &bulkResult{Command: "undo"}}
+			got, err := e.resumeTasks(newTasks())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resumeTasks() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resumeTasks() error = %v", err)
+			}
+			indexes := make([]int, 0, len(got))
+			for _, task := range got {
+				indexes = append(indexes, task.index)
+			}
+			if len(indexes) != len(tt.want) {
+				t.Fatalf("resumeTasks() = %v, want %v", indexes, tt.want)
+			}
+			for i := range indexes {
+				if indexes[i] != tt.want[i] {
+					t.Fatalf("resumeTasks() = %v, want %v", indexes, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// 进度条在另一个goroutine中刷新并读取状态，需要用 go test -race 运行
+func TestBulkRunWhileProgressRenders(t *testing.T) {
+	c := &WuKongIMContext{out: &renderer{format: outputJSON, out: io.Discard}}
+	e := &bulkExecutor{
+		ctx:     c,
+		bulkVar: &bulkVar{concurrency: 2, report: filepath.Join(t.TempDir(), "report.json")},
+		result:  &bulkResult{Command: "subscriber add"},
+	}
+	e.state.Store("Setup     ")
+	tasks := make([]*bulkTask, 0, 6)
+	for i := 0; i < cap(tasks); i++ {
+		tasks = append(tasks, &bulkTask{name: "g" + strconv.Itoa(i), index: i, run: func(ctx context.Context) error {
+			// 比进度条的刷新间隔长，保证执行期间进度条会刷新
+			time.Sleep(30 * time.Millisecond)
+			return nil
+		}})
+	}
+	e.result.Total = len(tasks)
+	e.items = make([]*bulkItem, len(tasks))
+	if err := e.run(context.Background(), tasks); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if e.result.Succeeded != len(tasks) {
+		t.Errorf("run() succeeded %d, want %d", e.result.Succeeded, len(tasks))
+	}
+}
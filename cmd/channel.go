@@ -1,10 +1,10 @@
 package cmd
 
 import (
-	"fmt"
+	"context"
 	"log"
 
-	"github.com/gosuri/uiprogress"
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
 	"github.com/spf13/cobra"
 )
 
@@ -12,19 +12,21 @@ type createVar struct {
 	num    int    // 数量
 	chType int    // 频道类型
 	prefix string // 频道前缀
+	ids    string // 频道ID来源，指定后忽略prefix和num
 }
 
 type channelCMD struct {
 	ctx       *WuKongIMContext
-	api       *API
+	api       wkapi.Interface
 	createVar *createVar
+	bulkVar   *bulkVar
 }
 
 func newChannelCMD(ctx *WuKongIMContext) *channelCMD {
 	c := &channelCMD{
 		ctx:       ctx,
-		api:       NewAPI(),
 		createVar: &createVar{},
+		bulkVar:   &bulkVar{},
 	}
 	return c
 }
@@ -41,7 +43,7 @@ func (c *channelCMD) CMD() *cobra.Command {
 		RunE:  c.runCreate,
 	}
 
-	cmd.AddCommand(create)
+	cmd.AddCommand(mutating(create))
 
 	c.initCreateVar(create)
 
@@ -52,57 +54,42 @@ func (c *channelCMD) initCreateVar(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&c.createVar.prefix, "prefix", "", "频道前缀")
 	cmd.Flags().IntVar(&c.createVar.num, "num", 0, "频道数量")
 	cmd.Flags().IntVar(&c.createVar.chType, "chType", 2, "频道类型")
+	cmd.Flags().StringVar(&c.createVar.ids, "channels", "", "频道ID（"+idSourceUsage+"）")
+	c.bulkVar.initFlags(cmd)
 }
 
 func (c *channelCMD) runCreate(cmd *cobra.Command, args []string) error {
 
-	c.api.SetBaseURL(c.ctx.opts.ServerAddr)
-
-	uiprogress.Start()
-	defer uiprogress.Stop()
-	var progress *uiprogress.Bar
-	progressNum := c.createVar.num
-	if c.createVar.num <= 0 {
-		progressNum = 1
+	api, err := c.ctx.newAPI()
+	if err != nil {
+		return err
 	}
-	progress = uiprogress.AddBar(progressNum).AppendCompleted().PrependElapsed()
-
-	progress.Width = progressWidth()
+	c.api = api
 
 	log.Printf("Starting create channel")
 
-	state := "Setup     "
-
-	progress.PrependFunc(func(b *uiprogress.Bar) string {
-		return state
-	})
-
-	if c.createVar.num <= 0 {
-		err := c.api.CreateChannel(&ChannelCreateReq{
-			ChannelInfoReq: ChannelInfoReq{
-				ChannelId:   c.createVar.prefix,
-				ChannelType: uint8(c.createVar.chType),
-			},
-		})
+	channelIds := []string{c.createVar.prefix}
+	if c.createVar.num > 0 || c.createVar.ids != "" {
+		channelIds, err = idsOrSequence(c.createVar.ids, c.createVar.prefix, c.createVar.num)
 		if err != nil {
 			return err
 		}
-		progress.Incr()
-	} else {
-		for i := 0; i < c.createVar.num; i++ {
-			err := c.api.CreateChannel(&ChannelCreateReq{
-				ChannelInfoReq: ChannelInfoReq{
-					ChannelId:   fmt.Sprintf("%s%d", c.createVar.prefix, i),
-					ChannelType: uint8(c.createVar.chType),
-				},
-			})
-			if err != nil {
-				return err
-			}
-			progress.Incr()
-		}
 	}
-	state = "Finished  "
 
-	return nil
+	tasks := make([]*bulkTask, 0, len(channelIds))
+	for _, channelId := range channelIds {
+		tasks = append(tasks, &bulkTask{
+			name:      channelId,
+			channelId: channelId,
+			run: func(ctx context.Context) error {
+				return c.api.CreateChannel(ctx, &wkapi.ChannelCreateReq{
+					ChannelInfoReq: wkapi.ChannelInfoReq{
+						ChannelId:   channelId,
+						ChannelType: uint8(c.createVar.chType),
+					},
+				})
+			},
+		})
+	}
+	return c.ctx.runBulk(cmd, c.bulkVar, tasks)
 }
@@ -8,10 +8,14 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/WuKongIM/WuKongIM/pkg/client"
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkutil"
 	wkproto "github.com/WuKongIM/WuKongIMGoProto"
 	"github.com/spf13/cobra"
 	"go.uber.org/atomic"
@@ -23,9 +27,31 @@ type CMD interface {
 }
 
 type Options struct {
-	ServerAddr  string
+	ServerAddr  string   // 主服务地址（Servers中的第一个）
+	Servers     []string // 集群部署时的所有http api地址
+	Strategy    string   // 多个地址时的选择策略 failover 或 round-robin
 	Description string
-	Token       string
+	Token       string            // 管理者token（对应服务端的managerToken）
+	Headers     map[string]string // 每次请求都携带的额外请求头（比如经过鉴权的反向代理）
+
+	Server   *ServerInfo   // 添加上下文时从服务端获取的信息，未校验时为nil
+	Defaults *FlagDefaults // 该上下文下命令行参数的默认值
+
+	ReadOnly  bool // 只读：禁止修改数据和产生负载的命令
+	Protected bool // 受保护：修改数据和产生负载的命令需要输入上下文名称确认
+
+	TokenEncrypted bool   // token是否加密保存
+	encryptedToken string // 加密保存的token，使用时才解密
+	passphrase     string // 解密token时输入的密码，保存时复用
+}
+
+// ServerInfo 校验上下文时从服务端（/varz）获取的服务信息
+type ServerInfo struct {
+	Version     string `json:"version"`      // 服务端版本
+	TCPAddr     string `json:"tcp_addr"`     // tcp地址
+	WSAddr      string `json:"ws_addr"`      // websocket地址
+	MonitorAddr string `json:"monitor_addr"` // 监控地址
+	VerifiedAt  string `json:"verified_at"`  // 校验时间
 }
 
 func NewOptions() *Options {
@@ -59,18 +85,20 @@ func (o *Options) ContextDir() string {
 
 }
 
+// Load 加载当前使用的上下文（meta中记录的上下文）
 func (o *Options) Load() error {
-	data, err := ioutil.ReadFile(o.metaFile())
+	name, err := o.Current()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
 		return err
 	}
-	if len(data) == 0 {
+	if name == "" {
 		return nil
 	}
-	name := string(data)
+	return o.LoadContext(name)
+}
+
+// LoadContext 加载指定名称的上下文
+func (o *Options) LoadContext(name string) error {
 	filen, err := o.ContextPath(name)
 	if err != nil {
 		return err
@@ -78,6 +106,9 @@ func (o *Options) Load() error {
 
 	optionData, err := ioutil.ReadFile(filen)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("unknown context %q", name)
+		}
 		return err
 	}
 	var optionMap map[string]interface{}
@@ -85,50 +116,315 @@ func (o *Options) Load() error {
 	if err != nil {
 		return err
 	}
+	err = o.fromMap(optionMap)
+	if err != nil {
+		return fmt.Errorf("invalid context %q: %w", name, err)
+	}
+	return nil
+}
+
+// fromMap 读取上下文文件（或者导入的上下文）的内容，字段类型不正确时返回错误
+func (o *Options) fromMap(optionMap map[string]interface{}) error {
 	if optionMap == nil {
 		return nil
 	}
-	if optionMap["url"] != nil {
-		o.ServerAddr = optionMap["url"].(string)
+	stringFields := []struct {
+		key   string
+		value *string
+	}{
+		{"url", &o.ServerAddr},
+		{"strategy", &o.Strategy},
+		{"description", &o.Description},
+		{"token", &o.Token},
+		{"token_encrypted", &o.encryptedToken},
+	}
+	for _, field := range stringFields {
+		if optionMap[field.key] == nil {
+			continue
+		}
+		value, ok := optionMap[field.key].(string)
+		if !ok {
+			return fmt.Errorf("invalid %q: expected a string, got %v", field.key, optionMap[field.key])
+		}
+		*field.value = value
 	}
-	if optionMap["description"] != nil {
-		o.Description = optionMap["description"].(string)
+	o.TokenEncrypted = o.encryptedToken != ""
+	boolFields := []struct {
+		key   string
+		value *bool
+	}{
+		{"read_only", &o.ReadOnly},
+		{"protected", &o.Protected},
 	}
-	if optionMap["token"] != nil {
-		o.Token = optionMap["token"].(string)
+	for _, field := range boolFields {
+		if optionMap[field.key] == nil {
+			continue
+		}
+		value, ok := optionMap[field.key].(bool)
+		if !ok {
+			return fmt.Errorf("invalid %q: expected true or false, got %v", field.key, optionMap[field.key])
+		}
+		*field.value = value
+	}
+	if optionMap["urls"] != nil {
+		urls, ok := optionMap["urls"].([]interface{})
+		if !ok {
+			return fmt.Errorf("invalid \"urls\": expected a list, got %v", optionMap["urls"])
+		}
+		servers := make([]string, 0, len(urls))
+		for _, u := range urls {
+			server, ok := u.(string)
+			if !ok {
+				return fmt.Errorf("invalid \"urls\": expected a list of strings, got %v", u)
+			}
+			servers = append(servers, server)
+		}
+		o.SetServers(strings.Join(servers, ","))
+	}
+	if optionMap["headers"] != nil {
+		headers, ok := optionMap["headers"].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid \"headers\": expected an object, got %v", optionMap["headers"])
+		}
+		o.Headers = make(map[string]string, len(headers))
+		for k, v := range headers {
+			value, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("invalid header %q: expected a string, got %v", k, v)
+			}
+			o.Headers[k] = value
+		}
+	}
+	objectFields := []struct {
+		key   string
+		value interface{}
+	}{
+		{"defaults", &o.Defaults},
+		{"server", &o.Server},
+	}
+	for _, field := range objectFields {
+		if optionMap[field.key] == nil {
+			continue
+		}
+		if _, ok := optionMap[field.key].(map[string]interface{}); !ok {
+			return fmt.Errorf("invalid %q: expected an object, got %v", field.key, optionMap[field.key])
+		}
+		err := json.Unmarshal([]byte(wkutil.ToJSON(optionMap[field.key])), field.value)
+		if err != nil {
+			return fmt.Errorf("invalid %q: %w", field.key, err)
+		}
 	}
 	return nil
 }
 
+// toMap 上下文保存到文件的内容，plain为true时token始终以明文输出（用于导出）
+func (o *Options) toMap(plain bool) (map[string]interface{}, error) {
+	optionMap := map[string]interface{}{
+		"url":         o.ServerAddr,
+		"description": o.Description,
+		"token":       o.Token,
+	}
+	if o.TokenEncrypted && !plain {
+		encryptedToken, err := o.sealToken()
+		if err != nil {
+			return nil, err
+		}
+		optionMap["token"] = ""
+		optionMap["token_encrypted"] = encryptedToken
+	}
+	if len(o.Headers) > 0 {
+		optionMap["headers"] = o.Headers
+	}
+	if len(o.Servers) > 1 {
+		optionMap["urls"] = o.Servers
+	}
+	if o.Strategy != "" {
+		optionMap["strategy"] = o.Strategy
+	}
+	if o.Server != nil {
+		optionMap["server"] = o.Server
+	}
+	if !o.Defaults.empty() {
+		optionMap["defaults"] = o.Defaults
+	}
+	if o.ReadOnly {
+		optionMap["read_only"] = true
+	}
+	if o.Protected {
+		optionMap["protected"] = true
+	}
+	return optionMap, nil
+}
+
+// Save 保存上下文并设置为当前使用的上下文
 func (o *Options) Save(name string) error {
+	err := o.SaveContext(name)
+	if err != nil {
+		return err
+	}
+	return o.Use(name)
+}
+
+// SaveContext 保存上下文，不改变当前使用的上下文
+func (o *Options) SaveContext(name string) error {
 	p, err := o.ContextPath(name)
 	if err != nil {
 		return err
 	}
-	j, err := json.MarshalIndent(map[string]interface{}{
-		"url":         o.ServerAddr,
-		"description": o.Description,
-		"token":       o.Token,
-	}, "", "  ")
+	optionMap, err := o.toMap(false)
+	if err != nil {
+		return err
+	}
+	j, err := json.MarshalIndent(optionMap, "", "  ")
 	if err != nil {
 		return err
 	}
+	return ioutil.WriteFile(p, j, 0600)
+}
+
+// Use 切换当前使用的上下文
+func (o *Options) Use(name string) error {
+	if !o.ContextExists(name) {
+		return fmt.Errorf("unknown context %q", name)
+	}
+	return ioutil.WriteFile(o.metaFile(), []byte(name), 0600)
+}
 
-	pf, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+// Remove 删除上下文，如果删除的是当前使用的上下文则同时清空meta
+func (o *Options) Remove(name string) error {
+	p, err := o.ContextPath(name)
 	if err != nil {
 		return err
 	}
-	_, err = pf.Write(j)
+	err = os.Remove(p)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("unknown context %q", name)
+		}
 		return err
 	}
+	current, err := o.Current()
+	if err != nil {
+		return err
+	}
+	if current == name {
+		return ioutil.WriteFile(o.metaFile(), []byte(""), 0600)
+	}
+	return nil
+}
+
+// Current 当前使用的上下文名称，没有则返回空
+func (o *Options) Current() (string, error) {
+	data, err := ioutil.ReadFile(o.metaFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ContextExists 上下文是否存在
+func (o *Options) ContextExists(name string) bool {
+	p, err := o.ContextPath(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(p)
+	return err == nil
+}
+
+// ContextNames 所有已保存的上下文名称
+func (o *Options) ContextNames() ([]string, error) {
+	entries, err := ioutil.ReadDir(o.ContextDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SetServers 设置服务地址，多个地址用逗号分隔
+func (o *Options) SetServers(servers string) {
+	addrs := splitServers(servers)
+	if len(addrs) == 0 {
+		o.ServerAddr = ""
+		o.Servers = nil
+		return
+	}
+	o.ServerAddr = addrs[0]
+	o.Servers = addrs
+}
 
-	mf, err := os.OpenFile(o.metaFile(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+// Endpoints 所有http api地址
+func (o *Options) Endpoints() []string {
+	if len(o.Servers) > 0 {
+		return o.Servers
+	}
+	if o.ServerAddr == "" {
+		return nil
+	}
+	return []string{o.ServerAddr}
+}
+
+// APIHeaders 请求服务端API时需要携带的请求头，token加密保存时会先解密
+func (o *Options) APIHeaders() (map[string]string, error) {
+	err := o.UnlockToken()
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(o.Headers)+1)
+	for k, v := range o.Headers {
+		headers[k] = v
+	}
+	if o.Token != "" {
+		headers[wkapi.TokenHeader] = o.Token
+	}
+	return headers, nil
+}
+
+// UnlockToken 解密加密保存的token，已经有明文token时不做处理
+func (o *Options) UnlockToken() error {
+	if o.Token != "" || o.encryptedToken == "" {
+		return nil
+	}
+	passphrase, err := readPassphrase(envPassphrase, "Passphrase for the context token: ", false)
+	if err != nil {
+		return err
+	}
+	token, err := wkutil.AesDecryptWithPassphrase(o.encryptedToken, passphrase)
 	if err != nil {
 		return err
 	}
-	_, err = mf.Write([]byte(name))
-	return err
+	o.Token = string(token)
+	o.passphrase = passphrase
+	return nil
+}
+
+// sealToken 加密token，token未解密时沿用原来的密文
+func (o *Options) sealToken() (string, error) {
+	if o.Token == "" {
+		return o.encryptedToken, nil
+	}
+	if o.passphrase == "" {
+		passphrase, err := readPassphrase(envPassphrase, "Passphrase to encrypt the context token: ", true)
+		if err != nil {
+			return "", err
+		}
+		o.passphrase = passphrase
+	}
+	return wkutil.AesEncryptWithPassphrase([]byte(o.Token), o.passphrase)
 }
 
 func (o *Options) metaFile() string {
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOptionsFromMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+		check   func(t *testing.T, o *Options)
+	}{
+		{
+			name: "valid",
+			data: `{"url":"http://a:5001","urls":["http://a:5001","http://b:5001"],"strategy":"round-robin","description":"d","token":"t","headers":{"X-A":"1"},"read_only":true}`,
+			check: func(t *testing.T, o *Options) {
+				if o.ServerAddr != "http://a:5001" || len(o.Servers) != 2 || o.Strategy != "round-robin" || o.Token != "t" || o.Headers["X-A"] != "1" || !o.ReadOnly {
+					t.Errorf("unexpected options %+v", o)
+				}
+			},
+		},
+		{
+			name: "defaults and server",
+			data: `{"url":"http://a:5001","defaults":{"flags":{"chNum":"10"}},"server":{"version":"v2.0.0","tcp_addr":"a:5100"},"protected":false}`,
+			check: func(t *testing.T, o *Options) {
+				if v, _ := o.Defaults.lookup("subscriber add", "chNum"); v != "10" || o.Server == nil || o.Server.TCPAddr != "a:5100" || o.Protected {
+					t.Errorf("unexpected options %+v", o)
+				}
+			},
+		},
+		{
+			name: "encrypted token",
+			data: `{"url":"http://a:5001","token":"","token_encrypted":"abc"}`,
+			check: func(t *testing.T, o *Options) {
+				if !o.TokenEncrypted || o.encryptedToken != "abc" {
+					t.Errorf("token not marked encrypted: %+v", o)
+				}
+			},
+		},
+		{name: "url not a string", data: `{"url":1}`, wantErr: `invalid "url"`},
+		{name: "strategy not a string", data: `{"strategy":true}`, wantErr: `invalid "strategy"`},
+		{name: "description not a string", data: `{"description":["x"]}`, wantErr: `invalid "description"`},
+		{name: "token not a string", data: `{"token":{"a":1}}`, wantErr: `invalid "token"`},
+		{name: "encrypted token not a string", data: `{"token_encrypted":2}`, wantErr: `invalid "token_encrypted"`},
+		{name: "urls not a list", data: `{"urls":"http://a:5001"}`, wantErr: `invalid "urls"`},
+		{name: "url in urls not a string", data: `{"urls":["http://a:5001",1]}`, wantErr: `invalid "urls"`},
+		{name: "read only as a string", data: `{"read_only":"true"}`, wantErr: `invalid "read_only"`},
+		{name: "protected as a number", data: `{"protected":1}`, wantErr: `invalid "protected"`},
+		{name: "headers not an object", data: `{"headers":"X-A=1"}`, wantErr: `invalid "headers"`},
+		{name: "header not a string", data: `{"headers":{"X-A":1}}`, wantErr: `invalid header "X-A"`},
+		{name: "defaults not an object", data: `{"defaults":[]}`, wantErr: `invalid "defaults"`},
+		{name: "default not a string", data: `{"defaults":{"flags":{"chNum":10}}}`, wantErr: `invalid "defaults"`},
+		{name: "server not an object", data: `{"server":"v2"}`, wantErr: `invalid "server"`},
+		{name: "server version not a string", data: `{"server":{"version":2}}`, wantErr: `invalid "server"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var optionMap map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.data), &optionMap); err != nil {
+				t.Fatal(err)
+			}
+			o := &Options{ServerAddr: "http://127.0.0.1:5001"} // NewOptions 会创建 ~/wukongim 下的目录
+			err := o.fromMap(optionMap)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("fromMap() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fromMap() error = %v", err)
+			}
+			tt.check(t, o)
+		})
+	}
+}
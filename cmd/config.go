@@ -0,0 +1,371 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// 默认值的作用范围
+const (
+	scopeGlobal  = "global"
+	scopeContext = "context"
+)
+
+// FlagDefaults 命令行参数的默认值，命令行中显式指定的参数优先
+type FlagDefaults struct {
+	Flags    map[string]string            `json:"flags,omitempty"`    // 对所有命令生效，参数名 -> 值
+	Commands map[string]map[string]string `json:"commands,omitempty"` // 只对指定命令生效，命令路径（如 "subscriber add"） -> 参数名 -> 值
+}
+
+// lookup 查找参数的默认值，指定命令的默认值优先
+func (f *FlagDefaults) lookup(command, flag string) (string, bool) {
+	if f == nil {
+		return "", false
+	}
+	if v, ok := f.Commands[command][flag]; ok {
+		return v, true
+	}
+	v, ok := f.Flags[flag]
+	return v, ok
+}
+
+func (f *FlagDefaults) set(command, flag, value string) {
+	if command == "" {
+		if f.Flags == nil {
+			f.Flags = make(map[string]string)
+		}
+		f.Flags[flag] = value
+		return
+	}
+	if f.Commands == nil {
+		f.Commands = make(map[string]map[string]string)
+	}
+	if f.Commands[command] == nil {
+		f.Commands[command] = make(map[string]string)
+	}
+	f.Commands[command][flag] = value
+}
+
+func (f *FlagDefaults) unset(command, flag string) bool {
+	if command == "" {
+		_, ok := f.Flags[flag]
+		delete(f.Flags, flag)
+		return ok
+	}
+	_, ok := f.Commands[command][flag]
+	delete(f.Commands[command], flag)
+	if len(f.Commands[command]) == 0 {
+		delete(f.Commands, command)
+	}
+	return ok
+}
+
+func (f *FlagDefaults) empty() bool {
+	return f == nil || (len(f.Flags) == 0 && len(f.Commands) == 0)
+}
+
+// CLIConfig 全局配置文件（~/wukongim/.config/config.json）
+type CLIConfig struct {
+	Defaults *FlagDefaults `json:"defaults,omitempty"`
+}
+
+func configPath() string {
+	return filepath.Join(filepath.Dir(NewOptions().ContextDir()), "config.json")
+}
+
+func loadCLIConfig() (*CLIConfig, error) {
+	cfg := &CLIConfig{}
+	data, err := ioutil.ReadFile(configPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return cfg, nil
+	}
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", configPath(), err)
+	}
+	return cfg, nil
+}
+
+func (c *CLIConfig) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configPath(), data, 0600)
+}
+
+// skipDefaultFlags 不能通过默认值设置的参数（用于选择上下文本身，以及每次执行都要明确指定的 --yes 和 --dry-run）
+var skipDefaultFlags = map[string]bool{
+	"context": true,
+	"server":  true,
+	"token":   true,
+	"help":    true,
+	"yes":     true,
+	"dry-run": true,
+}
+
+// commandKey 命令路径，不包含根命令，比如 "subscriber add"
+func commandKey(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+// applyFlagDefaults 为未显式指定的参数设置默认值，layers中靠前的优先
+func applyFlagDefaults(cmd *cobra.Command, layers ...*FlagDefaults) error {
+	command := commandKey(cmd)
+	var err error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || skipDefaultFlags[flag.Name] {
+			return
+		}
+		for _, layer := range layers {
+			value, ok := layer.lookup(command, flag.Name)
+			if !ok {
+				continue
+			}
+			if setErr := flag.Value.Set(value); setErr != nil {
+				err = fmt.Errorf("invalid default %q for --%s: %w", value, flag.Name, setErr)
+			}
+			return
+		}
+	})
+	return err
+}
+
+type configCMD struct {
+	ctx     *WuKongIMContext
+	scope   string
+	command string
+}
+
+func newConfigCMD(ctx *WuKongIMContext) *configCMD {
+	return &configCMD{
+		ctx: ctx,
+	}
+}
+
+func (c *configCMD) CMD() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage default flag values, globally or per context",
+	}
+
+	set := &cobra.Command{
+		Use:   "set <flag> <value>",
+		Short: "Set the default value of a flag",
+		RunE:  c.set,
+	}
+	unset := &cobra.Command{
+		Use:   "unset <flag>",
+		Short: "Remove the default value of a flag",
+		RunE:  c.unset,
+	}
+	list := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the configured defaults",
+		RunE:    c.list,
+	}
+	cmd.AddCommand(set)
+	cmd.AddCommand(unset)
+	cmd.AddCommand(list)
+
+	for _, sub := range []*cobra.Command{set, unset} {
+		sub.Flags().StringVar(&c.scope, "scope", scopeGlobal, "Where to store the default: global or context (the active context)")
+		sub.Flags().StringVar(&c.command, "command", "", "Only apply to this command, e.g. \"subscriber add\"")
+	}
+	return cmd
+}
+
+func (c *configCMD) set(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		cmd.Help()
+		return nil
+	}
+	flag, value := args[0], args[1]
+	err := c.checkFlag(cmd.Root(), flag, value)
+	if err != nil {
+		return err
+	}
+	return c.update(func(defaults *FlagDefaults) error {
+		defaults.set(c.command, flag, value)
+		return nil
+	})
+}
+
+func (c *configCMD) unset(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Help()
+		return nil
+	}
+	return c.update(func(defaults *FlagDefaults) error {
+		if !defaults.unset(c.command, args[0]) {
+			return fmt.Errorf("no default set for %q", args[0])
+		}
+		return nil
+	})
+}
+
+// update 修改指定作用范围的默认值并保存
+func (c *configCMD) update(fnc func(defaults *FlagDefaults) error) error {
+	switch c.scope {
+	case scopeGlobal:
+		cfg, err := loadCLIConfig()
+		if err != nil {
+			return err
+		}
+		if cfg.Defaults == nil {
+			cfg.Defaults = &FlagDefaults{}
+		}
+		err = fnc(cfg.Defaults)
+		if err != nil {
+			return err
+		}
+		if cfg.Defaults.empty() {
+			cfg.Defaults = nil
+		}
+		return cfg.save()
+	case scopeContext:
+		if c.ctx.contextName == "" {
+			return errors.New("no active context, use `wk context use <name>` to select one")
+		}
+		opts := NewOptions()
+		err := opts.LoadContext(c.ctx.contextName)
+		if err != nil {
+			return err
+		}
+		if opts.Defaults == nil {
+			opts.Defaults = &FlagDefaults{}
+		}
+		err = fnc(opts.Defaults)
+		if err != nil {
+			return err
+		}
+		return opts.SaveContext(c.ctx.contextName)
+	default:
+		return fmt.Errorf("invalid scope %q, must be %s or %s", c.scope, scopeGlobal, scopeContext)
+	}
+}
+
+// checkFlag 检查参数是否存在以及值是否合法
+func (c *configCMD) checkFlag(root *cobra.Command, name, value string) error {
+	switch {
+	case name == "yes" || name == "dry-run":
+		return fmt.Errorf("--%s can not have a default, give it on each run", name)
+	case skipDefaultFlags[name]:
+		return fmt.Errorf("--%s can not have a default, use `wk context` instead", name)
+	}
+	commands := []*cobra.Command{}
+	if c.command != "" {
+		target, rest, err := root.Find(strings.Fields(c.command))
+		if err != nil || len(rest) > 0 || target == root {
+			return fmt.Errorf("unknown command %q", c.command)
+		}
+		commands = append(commands, target)
+	} else {
+		commands = allCommands(root)
+	}
+	// 同名的参数在不同命令中的类型可能不同，默认值对所有有这个参数的命令都要有效
+	found := false
+	for _, command := range commands {
+		flag := command.Flags().Lookup(name)
+		if flag == nil {
+			continue
+		}
+		found = true
+		// 本次执行的是config命令，修改其它命令的参数值不会产生影响
+		err := flag.Value.Set(value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for --%s of %q: %w", value, name, commandKey(command), err)
+		}
+	}
+	if found {
+		return nil
+	}
+	if c.command != "" {
+		return fmt.Errorf("command %q has no flag --%s", c.command, name)
+	}
+	return fmt.Errorf("no command has a flag --%s", name)
+}
+
+func allCommands(cmd *cobra.Command) []*cobra.Command {
+	commands := []*cobra.Command{cmd}
+	for _, sub := range cmd.Commands() {
+		commands = append(commands, allCommands(sub)...)
+	}
+	return commands
+}
+
+// configList wk config list 输出的结果
+type configList struct {
+	Global          *FlagDefaults `json:"global,omitempty"`
+	Context         string        `json:"context,omitempty"`
+	ContextDefaults *FlagDefaults `json:"context_defaults,omitempty"`
+}
+
+func (c *configCMD) list(cmd *cobra.Command, args []string) error {
+	cfg, err := loadCLIConfig()
+	if err != nil {
+		return err
+	}
+	result := &configList{
+		Global: cfg.Defaults,
+	}
+	if c.ctx.contextName != "" {
+		result.Context = c.ctx.contextName
+		result.ContextDefaults = c.ctx.opts.Defaults
+	}
+	return c.ctx.out.render(result, func(out io.Writer) error {
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "SCOPE\tCOMMAND\tFLAG\tVALUE")
+		printDefaults(w, scopeGlobal, result.Global)
+		if result.Context != "" {
+			printDefaults(w, scopeContext+" "+result.Context, result.ContextDefaults)
+		}
+		return w.Flush()
+	})
+}
+
+func printDefaults(w *tabwriter.Writer, scope string, defaults *FlagDefaults) {
+	if defaults == nil {
+		return
+	}
+	for _, flag := range sortedKeys(defaults.Flags) {
+		fmt.Fprintf(w, "%s\t*\t--%s\t%s\n", scope, flag, defaults.Flags[flag])
+	}
+	commands := make([]string, 0, len(defaults.Commands))
+	for command := range defaults.Commands {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	for _, command := range commands {
+		for _, flag := range sortedKeys(defaults.Commands[command]) {
+			fmt.Fprintf(w, "%s\t%s\t--%s\t%s\n", scope, command, flag, defaults.Commands[command][flag])
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
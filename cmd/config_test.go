@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestFlagDefaultsSetUnset(t *testing.T) {
+	f := &FlagDefaults{}
+	if !f.empty() {
+		t.Fatal("new defaults should be empty")
+	}
+	f.set("", "chPrefix", "grp")
+	f.set("subscriber add", "chPrefix", "vip")
+	f.set("subscriber add", "chNum", "10")
+
+	lookups := []struct {
+		command, flag string
+		want          string
+		wantOK        bool
+	}{
+		{"subscriber add", "chPrefix", "vip", true},
+		{"subscriber remove", "chPrefix", "grp", true},
+		{"", "chPrefix", "grp", true},
+		{"subscriber add", "chNum", "10", true},
+		{"subscriber remove", "chNum", "", false},
+		{"subscriber add", "subNum", "", false},
+	}
+	for _, l := range lookups {
+		got, ok := f.lookup(l.command, l.flag)
+		if got != l.want || ok != l.wantOK {
+			t.Errorf("lookup(%q, %q) = %q, %v, want %q, %v", l.command, l.flag, got, ok, l.want, l.wantOK)
+		}
+	}
+
+	if !f.unset("subscriber add", "chPrefix") {
+		t.Error("unset() of a command default should report it existed")
+	}
+	if got, _ := f.lookup("subscriber add", "chPrefix"); got != "grp" {
+		t.Errorf("lookup() after unsetting the command default = %q, want the global grp", got)
+	}
+	if f.unset("subscriber add", "chPrefix") {
+		t.Error("unset() twice should report it did not exist")
+	}
+	if !f.unset("subscriber add", "chNum") {
+		t.Error("unset() of chNum should report it existed")
+	}
+	if _, ok := f.Commands["subscriber add"]; ok {
+		t.Error("command without defaults should be removed")
+	}
+	if !f.unset("", "chPrefix") || !f.empty() {
+		t.Errorf("defaults should be empty after unsetting everything: %+v", f)
+	}
+	if f.unset("", "missing") {
+		t.Error("unset() of a missing flag should report it did not exist")
+	}
+
+	var nilDefaults *FlagDefaults
+	if _, ok := nilDefaults.lookup("subscriber add", "chNum"); ok || !nilDefaults.empty() {
+		t.Error("nil defaults should be empty")
+	}
+}
+
+func TestApplyFlagDefaults(t *testing.T) {
+	newDefaults := func(flags map[string]string, commands map[string]map[string]string) *FlagDefaults {
+		return &FlagDefaults{Flags: flags, Commands: commands}
+	}
+	tests := []struct {
+		name    string
+		args    []string // 命令行中显式指定的参数
+		context *FlagDefaults
+		global  *FlagDefaults
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "no defaults",
+			want: map[string]string{"chPrefix": "ch", "chNum": "1", "context": ""},
+		},
+		{
+			name:   "global default",
+			global: newDefaults(map[string]string{"chPrefix": "grp"}, nil),
+			want:   map[string]string{"chPrefix": "grp", "chNum": "1"},
+		},
+		{
+			name:   "command default over flag default",
+			global: newDefaults(map[string]string{"chNum": "5"}, map[string]map[string]string{"subscriber add": {"chNum": "10"}, "channel create": {"chNum": "20"}}),
+			want:   map[string]string{"chNum": "10"},
+		},
+		{
+			name:    "context over global",
+			context: newDefaults(map[string]string{"chPrefix": "ctx"}, nil),
+			global:  newDefaults(nil, map[string]map[string]string{"subscriber add": {"chPrefix": "global", "chNum": "3"}}),
+			want:    map[string]string{"chPrefix": "ctx", "chNum": "3"},
+		},
+		{
+			name:    "command line over everything",
+			args:    []string{"--chPrefix", "cli"},
+			context: newDefaults(map[string]string{"chPrefix": "ctx"}, nil),
+			global:  newDefaults(map[string]string{"chPrefix": "global"}, nil),
+			want:    map[string]string{"chPrefix": "cli"},
+		},
+		{
+			name:   "context flag can not be defaulted",
+			global: newDefaults(map[string]string{"context": "prod"}, nil),
+			want:   map[string]string{"context": ""},
+		},
+		{
+			name:   "yes and dry-run can not be defaulted",
+			global: newDefaults(map[string]string{"yes": "true", "dry-run": "true"}, nil),
+			want:   map[string]string{"yes": "false", "dry-run": "false"},
+		},
+		{
+			name:    "invalid default",
+			global:  newDefaults(map[string]string{"chNum": "many"}, nil),
+			wantErr: `invalid default "many" for --chNum`,
+		},
+		{
+			name:   "invalid default of an explicit flag ignored",
+			args:   []string{"--chNum", "2"},
+			global: newDefaults(map[string]string{"chNum": "many"}, nil),
+			want:   map[string]string{"chNum": "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &cobra.Command{Use: "wk"}
+			parent := &cobra.Command{Use: "subscriber"}
+			cmd := &cobra.Command{Use: "add", Run: func(*cobra.Command, []string) {}}
+			root.AddCommand(parent)
+			parent.AddCommand(cmd)
+			cmd.Flags().String("chPrefix", "ch", "")
+			cmd.Flags().Int("chNum", 1, "")
+			cmd.Flags().String("context", "", "")
+			cmd.Flags().BoolP("yes", "y", false, "")
+			cmd.Flags().Bool("dry-run", false, "")
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := applyFlagDefaults(cmd, tt.context, tt.global)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyFlagDefaults() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyFlagDefaults() error = %v", err)
+			}
+			got := make(map[string]string, len(tt.want))
+			for name := range tt.want {
+				got[name] = cmd.Flags().Lookup(name).Value.String()
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flags = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigCheckFlag(t *testing.T) {
+	newRoot := func() *cobra.Command {
+		root := &cobra.Command{Use: "wk"}
+		user := &cobra.Command{Use: "user"}
+		set := &cobra.Command{Use: "set"}
+		kick := &cobra.Command{Use: "kick"}
+		root.AddCommand(user)
+		user.AddCommand(set, kick)
+		set.Flags().StringSlice("device", nil, "")
+		kick.Flags().Int("device", 0, "")
+		return root
+	}
+	tests := []struct {
+		name    string
+		command string
+		flag    string
+		value   string
+		wantErr string
+	}{
+		{name: "valid for every command", flag: "device", value: "1"},
+		// 第一个有这个参数的命令接受这个值，后面的命令不接受
+		{name: "invalid for a later command", flag: "device", value: "app", wantErr: `for --device of "user kick"`},
+		{name: "only the given command", command: "user set", flag: "device", value: "app"},
+		{name: "invalid for the given command", command: "user kick", flag: "device", value: "app", wantErr: `for --device of "user kick"`},
+		{name: "unknown flag", flag: "nope", value: "1", wantErr: "no command has a flag --nope"},
+		{name: "context can not be defaulted", flag: "context", value: "prod", wantErr: "can not have a default"},
+		{name: "yes can not be defaulted", flag: "yes", value: "true", wantErr: "can not have a default"},
+		{name: "dry-run can not be defaulted", flag: "dry-run", value: "true", wantErr: "can not have a default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &configCMD{command: tt.command}
+			err := c.checkFlag(newRoot(), tt.flag, tt.value)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkFlag() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("checkFlag() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sendgrid/rest"
+	"github.com/spf13/cobra"
+	terminal "golang.org/x/term"
+)
+
+// plannedRequest --dry-run 时记录的请求
+type plannedRequest struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+func newPlannedRequest(request rest.Request) *plannedRequest {
+	p := &plannedRequest{
+		Method: string(request.Method),
+		URL:    request.BaseURL,
+	}
+	if req, err := rest.BuildRequestObject(request); err == nil {
+		p.URL = req.URL.String()
+	}
+	if len(request.Body) > 0 {
+		if json.Valid(request.Body) {
+			p.Body = request.Body
+		} else {
+			p.Body, _ = json.Marshal(string(request.Body))
+		}
+	}
+	return p
+}
+
+func (p *plannedRequest) String() string {
+	if len(p.Body) == 0 {
+		return p.Method + " " + p.URL
+	}
+	return p.Method + " " + p.URL + " " + string(p.Body)
+}
+
+// confirmVar 修改数据前是否需要确认的参数
+type confirmVar struct {
+	yes          bool // 不需要确认
+	confirmAbove int  // 影响的数量超过该值时需要确认
+}
+
+func (v *confirmVar) initFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&v.yes, "yes", "y", false, "Do not ask for confirmation")
+	cmd.Flags().IntVar(&v.confirmAbove, "confirm-threshold", 100, "Ask for confirmation when more than this many users, channels or memberships are affected")
+}
+
+func (v *confirmVar) check() error {
+	if v.confirmAbove < 0 {
+		return fmt.Errorf("invalid --confirm-threshold %d, must not be negative", v.confirmAbove)
+	}
+	return nil
+}
+
+// confirmImpact 影响的数量超过 --confirm-threshold 时需要确认，--yes 或者已经确认过上下文时不需要
+func (c *WuKongIMContext) confirmImpact(command string, v *confirmVar, impact *bulkImpact) error {
+	if v.yes || c.confirmed || impact.size() <= v.confirmAbove {
+		return nil
+	}
+	return confirm(fmt.Sprintf("%s will affect %s on %s.", command, impact, c.target()))
+}
+
+// target 本次执行操作的对象，用于确认提示
+func (c *WuKongIMContext) target() string {
+	if c.contextName != "" {
+		return fmt.Sprintf("context %q", c.contextName)
+	}
+	return c.opts.ServerAddr
+}
+
+// confirm 在终端中询问是否继续，不在终端中执行时需要使用 --yes
+func confirm(prompt string) error {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("confirmation required, run in a terminal or pass --yes")
+	}
+	fmt.Fprintf(os.Stderr, "%s Continue? [y/N] ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errors.New("aborted")
+}
+
+// annotationMutating 标记修改服务端数据或者产生负载的命令
+const annotationMutating = "wk/mutating"
+
+// mutating 标记cmd会修改服务端数据或者产生负载，只读上下文中不能执行，受保护的上下文中需要确认
+func mutating(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[annotationMutating] = "true"
+	return cmd
+}
+
+func isMutating(cmd *cobra.Command) bool {
+	return cmd.Annotations[annotationMutating] == "true"
+}
+
+// checkMutation 检查当前上下文是否允许执行修改数据或者产生负载的命令，--dry-run 不会发送请求所以总是允许
+func (c *WuKongIMContext) checkMutation(cmd *cobra.Command) error {
+	if dryRun, err := cmd.Flags().GetBool("dry-run"); err == nil && dryRun {
+		return nil
+	}
+	if c.opts.ReadOnly {
+		return fmt.Errorf("context %q is read-only, %q is not allowed", c.contextName, commandKey(cmd))
+	}
+	if !c.opts.Protected || c.confirmed {
+		return nil
+	}
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("context %q is protected, run %q in a terminal to confirm", c.contextName, commandKey(cmd))
+	}
+	fmt.Fprintf(os.Stderr, "Context %q is protected. Type the context name to run %q: ", c.contextName, commandKey(cmd))
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(answer) != c.contextName {
+		return errors.New("context name does not match, aborted")
+	}
+	c.confirmed = true
+	return nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+// 测试中标准输入不是终端，需要确认时返回错误
+func TestConfirmImpact(t *testing.T) {
+	tests := []struct {
+		name      string
+		v         confirmVar
+		confirmed bool
+		uids      int
+		wantErr   bool
+	}{
+		{name: "below threshold", v: confirmVar{confirmAbove: 100}, uids: 100},
+		{name: "above threshold", v: confirmVar{confirmAbove: 100}, uids: 101, wantErr: true},
+		{name: "threshold 0", v: confirmVar{confirmAbove: 0}, uids: 1, wantErr: true},
+		{name: "yes", v: confirmVar{yes: true}, uids: 101},
+		{name: "context confirmed", v: confirmVar{}, confirmed: true, uids: 101},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &WuKongIMContext{opts: &Options{ServerAddr: "http://127.0.0.1:5001"}, confirmed: tt.confirmed}
+			err := c.confirmImpact("user system add", &tt.v, &bulkImpact{Uids: tt.uids})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "confirmation required") {
+					t.Fatalf("confirmImpact() error = %v, want confirmation required", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("confirmImpact() error = %v", err)
+			}
+		})
+	}
+}
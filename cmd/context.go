@@ -1,10 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
 	"github.com/spf13/cobra"
 )
 
@@ -13,6 +22,12 @@ type contextCMD struct {
 	description string
 	server      string
 	token       string
+	strategy    string
+	headers     map[string]string
+	encrypt     bool // 是否加密保存token
+	verify      bool // 保存前是否校验服务地址和token
+	readOnly    bool // 只读上下文
+	protected   bool // 受保护的上下文
 	ctx         *WuKongIMContext
 }
 
@@ -43,9 +58,78 @@ func (c *contextCMD) initSubCMD(cmd *cobra.Command) {
 		RunE:  c.add,
 	}
 	addCMD.Flags().StringVar(&c.description, "description", c.ctx.opts.Description, "Context description")
-	addCMD.Flags().StringVarP(&c.server, "server", "s", c.ctx.opts.ServerAddr, "Http  api server address")
+	addCMD.Flags().StringVarP(&c.server, "server", "s", strings.Join(c.ctx.opts.Endpoints(), ","), "Http  api server address, separate multiple cluster nodes with commas")
+	addCMD.Flags().StringVar(&c.strategy, "strategy", c.ctx.opts.Strategy, "How to pick among multiple servers: failover or round-robin")
 	addCMD.Flags().StringVar(&c.token, "token", "", "Token for connect WuKongIM")
+	addCMD.Flags().StringToStringVar(&c.headers, "header", nil, "Extra header sent with every request, e.g. --header X-Auth=secret")
+	addCMD.Flags().BoolVar(&c.encrypt, "encrypt-token", false, "Store the token encrypted with a passphrase (env "+envPassphrase+")")
+	addCMD.Flags().BoolVar(&c.verify, "verify", false, "Check the server and token before saving and record the server details")
+	addCMD.Flags().BoolVar(&c.readOnly, "read-only", false, "Block commands that change data or generate load (bench, mock, user create, ...)")
+	addCMD.Flags().BoolVar(&c.protected, "protected", false, "Require typing the context name before commands that change data or generate load")
 	cmd.AddCommand(addCMD)
+
+	listCMD := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List known contexts",
+		RunE:    c.list,
+	}
+	cmd.AddCommand(listCMD)
+
+	showCMD := &cobra.Command{
+		Use:   "show [name]",
+		Short: "Show the details of a context, defaults to the active one",
+		RunE:  c.show,
+	}
+	cmd.AddCommand(showCMD)
+
+	useCMD := &cobra.Command{
+		Use:   "use <name>",
+		Short: "Switch the active context",
+		RunE:  c.use,
+	}
+	cmd.AddCommand(useCMD)
+
+	rmCMD := &cobra.Command{
+		Use:   "rm <name>",
+		Short: "Remove a context",
+		RunE:  c.rm,
+	}
+	cmd.AddCommand(rmCMD)
+
+	editCMD := &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Change fields of an existing context in place",
+		RunE:  c.edit,
+	}
+	editCMD.Flags().String("description", "", "Context description")
+	editCMD.Flags().StringP("server", "s", "", "Http  api server address, separate multiple cluster nodes with commas")
+	editCMD.Flags().String("strategy", "", "How to pick among multiple servers: failover or round-robin")
+	editCMD.Flags().String("token", "", "Token for connect WuKongIM")
+	editCMD.Flags().StringToString("header", nil, "Set extra headers, an empty value removes the header, e.g. --header X-Auth=")
+	editCMD.Flags().Bool("encrypt-token", false, "Store the token encrypted with a passphrase (env "+envPassphrase+")")
+	editCMD.Flags().Bool("decrypt-token", false, "Store the token in plaintext again")
+	editCMD.Flags().Bool("verify", false, "Check the server and token before saving and record the server details")
+	editCMD.Flags().Bool("read-only", false, "Block commands that change data or generate load, --read-only=false to allow them again")
+	editCMD.Flags().Bool("protected", false, "Require typing the context name before commands that change data or generate load, --protected=false to turn off")
+	cmd.AddCommand(editCMD)
+
+	exportCMD := &cobra.Command{
+		Use:   "export [name...]",
+		Short: "Export contexts to a passphrase encrypted bundle, defaults to all contexts",
+		RunE:  c.export,
+	}
+	exportCMD.Flags().StringP("file", "f", "", "Bundle file to write, defaults to stdout")
+	cmd.AddCommand(exportCMD)
+
+	importCMD := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import contexts from a bundle created by export",
+		RunE:  c.importBundle,
+	}
+	importCMD.Flags().Bool("force", false, "Overwrite contexts that already exist")
+	importCMD.Flags().Bool("encrypt-token", false, "Store the imported tokens encrypted with a passphrase (env "+envPassphrase+")")
+	cmd.AddCommand(importCMD)
 }
 
 func (c *contextCMD) add(cmd *cobra.Command, args []string) error {
@@ -57,10 +141,476 @@ func (c *contextCMD) add(cmd *cobra.Command, args []string) error {
 	if !validName(name) {
 		return errors.New("invalid name")
 	}
+	if err := checkStrategy(c.strategy); err != nil {
+		return err
+	}
 	c.ctx.opts.Description = c.description
-	c.ctx.opts.ServerAddr = c.server
+	c.ctx.opts.SetServers(c.server)
+	c.ctx.opts.Strategy = c.strategy
 	c.ctx.opts.Token = c.token
-	return c.ctx.opts.Save(name)
+	c.ctx.opts.Headers = c.headers
+	c.ctx.opts.TokenEncrypted = c.encrypt
+	c.ctx.opts.encryptedToken = ""
+	c.ctx.opts.Server = nil
+	c.ctx.opts.ReadOnly = c.readOnly
+	c.ctx.opts.Protected = c.protected
+	if c.verify {
+		err := c.verifyContext(cmd.Context(), c.ctx.opts)
+		if err != nil {
+			return err
+		}
+	}
+	err := c.ctx.opts.Save(name)
+	if err != nil {
+		return err
+	}
+	return c.printContext(name, c.ctx.opts)
+}
+
+// contextItem wk context list 输出的一个上下文
+type contextItem struct {
+	Name        string   `json:"name"`
+	URLs        []string `json:"urls"`
+	Description string   `json:"description"`
+	ReadOnly    bool     `json:"read_only"`
+	Protected   bool     `json:"protected"`
+	Current     bool     `json:"current"`
+}
+
+func (c *contextCMD) list(cmd *cobra.Command, args []string) error {
+	names, err := c.ctx.opts.ContextNames()
+	if err != nil {
+		return err
+	}
+	current, err := c.ctx.opts.Current()
+	if err != nil {
+		return err
+	}
+	items := make([]*contextItem, 0, len(names))
+	for _, name := range names {
+		opts := NewOptions()
+		err = opts.LoadContext(name)
+		if err != nil {
+			return err
+		}
+		items = append(items, &contextItem{
+			Name:        name,
+			URLs:        opts.Endpoints(),
+			Description: opts.Description,
+			ReadOnly:    opts.ReadOnly,
+			Protected:   opts.Protected,
+			Current:     name == current,
+		})
+	}
+	return c.ctx.out.render(items, func(out io.Writer) error {
+		if len(items) == 0 {
+			fmt.Fprintln(out, "No known contexts")
+			return nil
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tURL\tMODE\tDESCRIPTION")
+		for _, item := range items {
+			name := item.Name
+			if item.Current {
+				name += "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, strings.Join(item.URLs, ","), contextMode(item.ReadOnly, item.Protected), item.Description)
+		}
+		return w.Flush()
+	})
+}
+
+func (c *contextCMD) show(cmd *cobra.Command, args []string) error {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	} else {
+		if c.ctx.contextName == "" {
+			return errors.New("no active context, use `wk context use <name>` to select one")
+		}
+		name = c.ctx.contextName
+	}
+	opts := NewOptions()
+	err := opts.LoadContext(name)
+	if err != nil {
+		return err
+	}
+	return c.printContext(name, opts)
+}
+
+func (c *contextCMD) use(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Help()
+		return nil
+	}
+	name := args[0]
+	err := c.ctx.opts.Use(name)
+	if err != nil {
+		return err
+	}
+	return c.printResult(name, "using")
+}
+
+func (c *contextCMD) rm(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Help()
+		return nil
+	}
+	name := args[0]
+	err := c.ctx.opts.Remove(name)
+	if err != nil {
+		return err
+	}
+	return c.printResult(name, "removed")
+}
+
+// contextResult wk context use/rm/import 的结果
+type contextResult struct {
+	Context string `json:"context"`
+	Result  string `json:"result"` // using, removed, imported
+}
+
+// printResult 输出对上下文的操作结果
+func (c *contextCMD) printResult(name, result string) error {
+	return c.ctx.out.render(&contextResult{Context: name, Result: result}, func(out io.Writer) error {
+		switch result {
+		case "using":
+			fmt.Fprintf(out, "Using context %q\n", name)
+		case "removed":
+			fmt.Fprintf(out, "Removed context %q\n", name)
+		}
+		return nil
+	})
+}
+
+func (c *contextCMD) edit(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Help()
+		return nil
+	}
+	name := args[0]
+	opts := NewOptions()
+	err := opts.LoadContext(name)
+	if err != nil {
+		return err
+	}
+	flags := cmd.Flags()
+	if flags.Changed("description") {
+		opts.Description, _ = flags.GetString("description")
+	}
+	if flags.Changed("server") {
+		server, _ := flags.GetString("server")
+		opts.SetServers(server)
+	}
+	if flags.Changed("strategy") {
+		opts.Strategy, _ = flags.GetString("strategy")
+		if err := checkStrategy(opts.Strategy); err != nil {
+			return err
+		}
+	}
+	if flags.Changed("token") {
+		opts.Token, _ = flags.GetString("token")
+	}
+	encrypt, _ := flags.GetBool("encrypt-token")
+	decrypt, _ := flags.GetBool("decrypt-token")
+	if encrypt && decrypt {
+		return errors.New("--encrypt-token and --decrypt-token can not be used together")
+	}
+	if encrypt || decrypt {
+		err = opts.UnlockToken()
+		if err != nil {
+			return err
+		}
+		opts.TokenEncrypted = encrypt
+	}
+	if flags.Changed("header") {
+		headers, _ := flags.GetStringToString("header")
+		if opts.Headers == nil {
+			opts.Headers = make(map[string]string, len(headers))
+		}
+		for k, v := range headers {
+			if v == "" {
+				delete(opts.Headers, k)
+				continue
+			}
+			opts.Headers[k] = v
+		}
+	}
+	if flags.Changed("read-only") {
+		opts.ReadOnly, _ = flags.GetBool("read-only")
+	}
+	if flags.Changed("protected") {
+		opts.Protected, _ = flags.GetBool("protected")
+	}
+	if verify, _ := flags.GetBool("verify"); verify {
+		err = c.verifyContext(cmd.Context(), opts)
+		if err != nil {
+			return err
+		}
+	}
+	err = opts.SaveContext(name)
+	if err != nil {
+		return err
+	}
+	return c.printContext(name, opts)
+}
+
+// verifyContext 校验所有服务地址是否可用、token是否正确，并记录服务端信息
+func (c *contextCMD) verifyContext(ctx context.Context, opts *Options) error {
+	endpoints := opts.Endpoints()
+	if len(endpoints) == 0 {
+		return errors.New("no server address configured")
+	}
+	for _, endpoint := range endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid server address %q, expected something like http://127.0.0.1:5001", endpoint)
+		}
+	}
+	for i, endpoint := range endpoints {
+		api, err := newAPI(opts, wkapi.WithEndpoints(endpoint))
+		if err != nil {
+			return err
+		}
+		varz, err := api.Varz(ctx)
+		if err != nil {
+			if errors.Is(err, wkapi.ErrUnauthorized) {
+				return fmt.Errorf("%s: token rejected by server, check --token: %w", endpoint, err)
+			}
+			return fmt.Errorf("%s: verify failed: %w", endpoint, err)
+		}
+		// 校验进度输出到stderr，不影响json和yaml的输出
+		fmt.Fprintf(os.Stderr, "[✓] %s is WuKongIM %s\n", endpoint, varz.Version)
+		if i == 0 {
+			opts.Server = &ServerInfo{
+				Version:     varz.Version,
+				TCPAddr:     varz.TCPAddr,
+				WSAddr:      varz.WSAddr,
+				MonitorAddr: varz.MonitorAddr,
+				VerifiedAt:  time.Now().Format(time.RFC3339),
+			}
+		}
+	}
+	return nil
+}
+
+// contextDetail wk context show/add/edit 输出的上下文
+type contextDetail struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	URLs        []string          `json:"urls"`
+	Strategy    string            `json:"strategy,omitempty"`
+	ReadOnly    bool              `json:"read_only"`
+	Protected   bool              `json:"protected"`
+	Token       string            `json:"token,omitempty"` // 脱敏后的token
+	Server      *ServerInfo       `json:"server,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"` // 脱敏后的请求头
+}
+
+func (c *contextCMD) printContext(name string, opts *Options) error {
+	detail := &contextDetail{
+		Name:        name,
+		Description: opts.Description,
+		URLs:        opts.Endpoints(),
+		ReadOnly:    opts.ReadOnly,
+		Protected:   opts.Protected,
+		Server:      opts.Server,
+	}
+	if len(detail.URLs) > 1 {
+		detail.Strategy = opts.Strategy
+		if detail.Strategy == "" {
+			detail.Strategy = wkapi.StrategyFailover
+		}
+	}
+	if opts.TokenEncrypted {
+		detail.Token = "(encrypted)"
+	} else if opts.Token != "" {
+		detail.Token = maskToken(opts.Token)
+	}
+	if len(opts.Headers) > 0 {
+		detail.Headers = make(map[string]string, len(opts.Headers))
+		for k, v := range opts.Headers {
+			detail.Headers[k] = maskToken(v)
+		}
+	}
+	return c.ctx.out.render(detail, func(out io.Writer) error {
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "WuKongIM Configuration Context %q\n", name)
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "  Description: %s\n", detail.Description)
+		fmt.Fprintf(out, "  Server URLs: %s\n", strings.Join(detail.URLs, ", "))
+		if detail.Strategy != "" {
+			fmt.Fprintf(out, "     Strategy: %s\n", detail.Strategy)
+		}
+		if detail.ReadOnly || detail.Protected {
+			fmt.Fprintf(out, "         Mode: %s\n", contextMode(detail.ReadOnly, detail.Protected))
+		}
+		if detail.Token != "" {
+			fmt.Fprintf(out, "        Token: %s\n", detail.Token)
+		}
+		if detail.Server != nil {
+			fmt.Fprintf(out, "      Version: %s\n", detail.Server.Version)
+			fmt.Fprintf(out, "     TCP Addr: %s\n", detail.Server.TCPAddr)
+			if detail.Server.WSAddr != "" {
+				fmt.Fprintf(out, "      WS Addr: %s\n", detail.Server.WSAddr)
+			}
+			if detail.Server.MonitorAddr != "" {
+				fmt.Fprintf(out, " Monitor Addr: %s\n", detail.Server.MonitorAddr)
+			}
+			fmt.Fprintf(out, "  Verified At: %s\n", detail.Server.VerifiedAt)
+		}
+		if len(detail.Headers) > 0 {
+			keys := make([]string, 0, len(detail.Headers))
+			for k := range detail.Headers {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			fmt.Fprintln(out, "      Headers:")
+			for _, k := range keys {
+				fmt.Fprintf(out, "               %s: %s\n", k, detail.Headers[k])
+			}
+		}
+		fmt.Fprintln(out)
+		return nil
+	})
+}
+
+// contextMode 上下文的保护模式
+func contextMode(readOnly, protected bool) string {
+	switch {
+	case readOnly && protected:
+		return "read-only,protected"
+	case readOnly:
+		return "read-only"
+	case protected:
+		return "protected"
+	}
+	return "-"
+}
+
+func (c *contextCMD) export(cmd *cobra.Command, args []string) error {
+	names := args
+	if len(names) == 0 {
+		var err error
+		names, err = c.ctx.opts.ContextNames()
+		if err != nil {
+			return err
+		}
+	}
+	if len(names) == 0 {
+		return errors.New("no contexts to export")
+	}
+	contexts := make(map[string]map[string]interface{}, len(names))
+	for _, name := range names {
+		opts := NewOptions()
+		err := opts.LoadContext(name)
+		if err != nil {
+			return err
+		}
+		err = opts.UnlockToken()
+		if err != nil {
+			return fmt.Errorf("context %q: %w", name, err)
+		}
+		contexts[name], err = opts.toMap(true)
+		if err != nil {
+			return err
+		}
+	}
+	passphrase, err := readPassphrase(envBundlePassphrase, "Passphrase for the bundle: ", true)
+	if err != nil {
+		return err
+	}
+	data, err := encodeContextBundle(contexts, passphrase)
+	if err != nil {
+		return err
+	}
+	file, _ := cmd.Flags().GetString("file")
+	if file == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	err = ioutil.WriteFile(file, data, 0600)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d contexts to %s\n", len(contexts), file)
+	return nil
+}
+
+func (c *contextCMD) importBundle(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Help()
+		return nil
+	}
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase(envBundlePassphrase, "Passphrase for the bundle: ", false)
+	if err != nil {
+		return err
+	}
+	contexts, err := decodeContextBundle(data, passphrase)
+	if err != nil {
+		return err
+	}
+	force, _ := cmd.Flags().GetBool("force")
+	encrypt, _ := cmd.Flags().GetBool("encrypt-token")
+
+	names := make([]string, 0, len(contexts))
+	for name := range contexts {
+		if !validName(name) {
+			return fmt.Errorf("invalid context name %q in bundle", name)
+		}
+		if c.ctx.opts.ContextExists(name) && !force {
+			return fmt.Errorf("context %q already exists, use --force to overwrite it", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// 先检查所有上下文，避免只导入了一部分
+	imported := make(map[string]*Options, len(names))
+	for _, name := range names {
+		opts := NewOptions()
+		err = opts.fromMap(contexts[name])
+		if err != nil {
+			return fmt.Errorf("invalid context %q in bundle: %w", name, err)
+		}
+		imported[name] = opts
+	}
+
+	var passphraseAtRest string
+	for _, name := range names {
+		opts := imported[name]
+		if encrypt && opts.Token != "" {
+			opts.TokenEncrypted = true
+			opts.passphrase = passphraseAtRest
+		}
+		err = opts.SaveContext(name)
+		if err != nil {
+			return err
+		}
+		passphraseAtRest = opts.passphrase
+	}
+	results := make([]*contextResult, 0, len(names))
+	for _, name := range names {
+		results = append(results, &contextResult{Context: name, Result: "imported"})
+	}
+	return c.ctx.out.render(results, func(out io.Writer) error {
+		for _, result := range results {
+			fmt.Fprintf(out, "Imported context %q\n", result.Context)
+		}
+		return nil
+	})
+}
+
+// maskToken 只显示token的首尾字符，避免在终端中泄露
+func maskToken(token string) string {
+	if len(token) <= 4 {
+		return strings.Repeat("*", len(token))
+	}
+	return token[:2] + strings.Repeat("*", len(token)-4) + token[len(token)-2:]
 }
 
 func validName(name string) bool {
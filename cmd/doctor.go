@@ -1,25 +1,27 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
 	"github.com/spf13/cobra"
 )
 
 type doctorCMD struct {
 	ctx *WuKongIMContext
-	api *API
+	api wkapi.Interface
 }
 
 func newDoctorCMD(ctx *WuKongIMContext) *doctorCMD {
 	return &doctorCMD{
 		ctx: ctx,
-		api: NewAPI(),
 	}
 }
 
@@ -32,76 +34,151 @@ func (d *doctorCMD) CMD() *cobra.Command {
 	return cmd
 }
 
-func (d *doctorCMD) run(cmd *cobra.Command, args []string) error {
-	fmt.Println("Checking service status...")
+// doctorReport doctor的检查结果
+type doctorReport struct {
+	Nodes  []*doctorNode `json:"nodes"`
+	Issues int           `json:"issues"` // 发现的问题数量
+}
 
-	issueCount := 0
+// doctorNode 一个节点的检查结果
+type doctorNode struct {
+	Endpoint string           `json:"endpoint"`
+	Version  string           `json:"version,omitempty"` // 服务端版本
+	Services []*doctorService `json:"services"`
+	Error    string           `json:"error,omitempty"` // 检查失败的原因
+}
 
-	// http server
-	check, port, err := d.checkTCP(d.ctx.opts.ServerAddr)
-	if err != nil {
-		return err
-	}
-	if check {
-		fmt.Printf("\x1B[32m%s %d\x1b[0m\n", "[✓] Http Service is running in", port)
-	} else {
-		fmt.Printf("\x1B[31m%s %d\x1b[0m\n", "[x] Http Service is not running in", port)
-		issueCount++
+// doctorService 一项服务的检查结果
+type doctorService struct {
+	Name     string `json:"name"`
+	Port     int    `json:"port"`
+	Running  bool   `json:"running"`
+	Required bool   `json:"required"` // 未运行时是否算作问题
+}
+
+func (d *doctorCMD) run(cmd *cobra.Command, args []string) error {
+	if !d.ctx.out.structured() {
+		fmt.Println("Checking service status...")
 	}
-	if check {
-		d.api.SetBaseURL(d.ctx.opts.ServerAddr)
 
-		// 请求服务状态
-		varz, err := d.api.Varz()
+	report := &doctorReport{
+		Nodes: make([]*doctorNode, 0),
+	}
+	failed := 0
+	for _, endpoint := range d.ctx.opts.Endpoints() {
+		node, err := d.checkEndpoint(cmd.Context(), endpoint)
+		issues := 0
 		if err != nil {
-			return err
+			if cmd.Context().Err() != nil {
+				return err
+			}
+			// 一个节点检查失败时继续检查其它节点
+			node.Error = err.Error()
+			issues++
 		}
-		// tcp server
-		check, port, err = d.checkTCP(varz.TCPAddr)
-		if err != nil {
-			return err
+		for _, service := range node.Services {
+			if service.Required && !service.Running {
+				issues++
+			}
 		}
-		if check {
-			fmt.Printf("\x1B[32m%s %d\x1b[0m\n", "[✓] TCP Service is running in", port)
-		} else {
-			fmt.Printf("\x1B[31m%s %d\x1b[0m\n", "[x] TCP Service is not running in", port)
-			issueCount++
+		// 无法访问、检查失败或者必需的服务没有运行的节点都算作失败
+		if issues > 0 {
+			failed++
 		}
-
-		// websocket server
-		if strings.TrimSpace(varz.WSAddr) != "" {
-			check, port, err = d.checkTCP(varz.WSAddr)
-			if err != nil {
-				return err
+		report.Issues += issues
+		report.Nodes = append(report.Nodes, node)
+	}
+	err := d.ctx.out.render(report, func(w io.Writer) error {
+		for _, node := range report.Nodes {
+			if len(report.Nodes) > 1 {
+				fmt.Fprintf(w, "\nNode %s\n", node.Endpoint)
 			}
-			if check {
-				fmt.Printf("\x1B[32m%s %d\x1b[0m\n", "[✓] Websocket Service is running in", port)
-			} else {
-				fmt.Printf("\x1B[31m%s %d\x1b[0m\n", "[x] Websocket Service is not running in", port)
+			for _, service := range node.Services {
+				if service.Running {
+					fmt.Fprintln(w, d.ctx.out.colored(colorGreen, fmt.Sprintf("[✓] %s Service is running in %d", service.Name, service.Port)))
+				} else {
+					fmt.Fprintln(w, d.ctx.out.colored(colorRed, fmt.Sprintf("[x] %s Service is not running in %d", service.Name, service.Port)))
+				}
 			}
+			if node.Error != "" {
+				fmt.Fprintln(w, d.ctx.out.colored(colorRed, fmt.Sprintf("[x] Check failed: %s", node.Error)))
+			}
+		}
+		if report.Issues > 0 {
+			fmt.Fprintln(w, d.ctx.out.colored(colorRed, fmt.Sprintf("Found %d issues", report.Issues)))
+		} else {
+			fmt.Fprintln(w, d.ctx.out.colored(colorGreen, "• No issues found!"))
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d nodes have issues", failed, len(report.Nodes))
+	}
+	return nil
+}
 
-		// monitor server
-		if varz.MonitorOn == 1 {
-			check, port, err = d.checkTCP(varz.MonitorAddr)
-			if err != nil {
-				return err
-			}
-			if check {
-				fmt.Printf("\x1B[32m%s %d\x1b[0m\n", "[✓] Monitor Service is running in", port)
-			} else {
-				fmt.Printf("\x1B[31m%s %d\x1b[0m\n", "[x] Monitor Service is not running in", port)
-				issueCount++
-			}
+// checkEndpoint 检查一个节点的各项服务，失败时也返回已经检查的结果
+func (d *doctorCMD) checkEndpoint(ctx context.Context, endpoint string) (*doctorNode, error) {
+	node := &doctorNode{
+		Endpoint: endpoint,
+		Services: make([]*doctorService, 0),
+	}
+	check := func(name, addr string, required bool) (bool, error) {
+		running, port, err := d.checkTCP(addr)
+		if err != nil {
+			return false, err
 		}
+		node.Services = append(node.Services, &doctorService{
+			Name:     name,
+			Port:     port,
+			Running:  running,
+			Required: required,
+		})
+		return running, nil
 	}
-	if issueCount > 0 {
-		fmt.Printf("\x1B[31m%s \x1b[0m\n", fmt.Sprintf("Found %d issues", issueCount))
-	} else {
-		fmt.Printf("\x1B[32m%s \x1b[0m\n", "• No issues found!")
+
+	// http server
+	running, err := check("Http", endpoint, true)
+	if err != nil || !running {
+		return node, err
 	}
 
-	return nil
+	api, err := d.ctx.newAPI(wkapi.WithEndpoints(endpoint))
+	if err != nil {
+		return node, err
+	}
+	d.api = api
+
+	// 请求服务状态
+	varz, err := d.api.Varz(ctx)
+	if err != nil {
+		return node, err
+	}
+	node.Version = varz.Version
+
+	// tcp server
+	_, err = check("TCP", varz.TCPAddr, true)
+	if err != nil {
+		return node, err
+	}
+	// websocket server
+	if strings.TrimSpace(varz.WSAddr) != "" {
+		_, err = check("Websocket", varz.WSAddr, false)
+		if err != nil {
+			return node, err
+		}
+	}
+	// monitor server
+	if varz.MonitorOn == 1 {
+		_, err = check("Monitor", varz.MonitorAddr, true)
+		if err != nil {
+			return node, err
+		}
+	}
+	return node, nil
 }
 
 func (d *doctorCMD) checkTCP(tcpAddr string) (bool, int, error) {
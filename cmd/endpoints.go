@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
+)
+
+func validStrategy(strategy string) bool {
+	switch strategy {
+	case "", wkapi.StrategyFailover, wkapi.StrategyRoundRobin:
+		return true
+	default:
+		return false
+	}
+}
+
+func checkStrategy(strategy string) error {
+	if !validStrategy(strategy) {
+		return fmt.Errorf("invalid strategy %q, must be %s or %s", strategy, wkapi.StrategyFailover, wkapi.StrategyRoundRobin)
+	}
+	return nil
+}
+
+// splitServers 解析逗号分隔的服务地址
+func splitServers(servers string) []string {
+	addrs := make([]string, 0)
+	for _, addr := range strings.Split(servers, ",") {
+		addr = strings.TrimRight(strings.TrimSpace(addr), "/")
+		if addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// idSourceUsage ID来源的格式说明，用于参数的帮助信息
+const idSourceUsage = "usr[100-199] range, comma separated list, @file (one ID per line or CSV, first column) or - for stdin"
+
+// maxIDs 一个ID来源最多展开的ID数量，避免写错范围时占满内存
+const maxIDs = 10000000
+
+// stdinUsed 标准输入只能被一个ID来源读取
+var stdinUsed bool
+
+// parseIDs 解析ID来源，支持以下格式，结果去重并保持顺序：
+//
+//	usr[100-199]       范围，保留前导0（usr[001-100]）
+//	u1,u2,grp[1-3]     列表
+//	@ids.txt           文件，每行一个ID，或者CSV（取第一列，表头uid/channel_id/id会被跳过）
+//	-                  从标准输入读取，格式与文件相同
+func parseIDs(spec string) ([]string, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "":
+		return nil, nil
+	case spec == "-":
+		if stdinUsed {
+			return nil, errors.New("stdin (-) can only be used once")
+		}
+		stdinUsed = true
+		return readIDs(os.Stdin, "stdin")
+	case strings.HasPrefix(spec, "@"):
+		f, err := os.Open(spec[1:])
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readIDs(f, spec[1:])
+	}
+	ids := newIDSet()
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		expanded, err := expandIDRange(item)
+		if err != nil {
+			return nil, err
+		}
+		err = ids.add(expanded...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids.list, nil
+}
+
+// expandIDs 展开多个ID来源（比如可以重复指定的参数）
+func expandIDs(specs []string) ([]string, error) {
+	ids := newIDSet()
+	for _, spec := range specs {
+		expanded, err := parseIDs(spec)
+		if err != nil {
+			return nil, err
+		}
+		err = ids.add(expanded...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids.list, nil
+}
+
+// idsOrSequence 指定了ID来源时使用ID来源，否则生成 prefix0 到 prefix(num-1)
+func idsOrSequence(spec string, prefix string, num int) ([]string, error) {
+	if strings.TrimSpace(spec) != "" {
+		return parseIDs(spec)
+	}
+	ids := make([]string, 0, num)
+	for i := 0; i < num; i++ {
+		ids = append(ids, prefix+strconv.Itoa(i))
+	}
+	return ids, nil
+}
+
+// expandIDRange 展开ID中的范围，可以有多个范围（g[1-2]u[1-2] 展开为 g1u1 g1u2 g2u1 g2u2）
+func expandIDRange(item string) ([]string, error) {
+	start := strings.Index(item, "[")
+	if start < 0 {
+		if strings.Contains(item, "]") {
+			return nil, fmt.Errorf("invalid id %q, unmatched ]", item)
+		}
+		return []string{item}, nil
+	}
+	end := strings.Index(item[start:], "]")
+	if end < 0 {
+		return nil, fmt.Errorf("invalid id %q, unmatched [", item)
+	}
+	end += start
+	from, to, ok := strings.Cut(item[start+1:end], "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid range %q in %q, expected [from-to]", item[start:end+1], item)
+	}
+	lo, err1 := strconv.Atoi(from)
+	hi, err2 := strconv.Atoi(to)
+	if err1 != nil || err2 != nil || lo < 0 || lo > hi {
+		return nil, fmt.Errorf("invalid range %q in %q, expected [from-to] with 0 <= from <= to", item[start:end+1], item)
+	}
+	if hi-lo >= maxIDs {
+		return nil, fmt.Errorf("range %q in %q is too large, at most %d ids", item[start:end+1], item, maxIDs)
+	}
+	format := "%d"
+	if len(from) > 1 && from[0] == '0' {
+		format = "%0" + strconv.Itoa(len(from)) + "d"
+	}
+	rest, err := expandIDRange(item[end+1:])
+	if err != nil {
+		return nil, err
+	}
+	if (hi-lo+1)*len(rest) > maxIDs {
+		return nil, fmt.Errorf("%q expands to more than %d ids", item, maxIDs)
+	}
+	ids := make([]string, 0, (hi-lo+1)*len(rest))
+	for i := lo; i <= hi; i++ {
+		prefix := item[:start] + fmt.Sprintf(format, i)
+		for _, r := range rest {
+			ids = append(ids, prefix+r)
+		}
+	}
+	return ids, nil
+}
+
+// readIDs 读取每行一个ID或者CSV，跳过空行、#开头的注释和表头
+func readIDs(r io.Reader, name string) ([]string, error) {
+	ids := newIDSet()
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read ids from %s: %w", name, err)
+		}
+		id := strings.TrimSpace(record[0])
+		if first {
+			first = false
+			switch strings.ToLower(id) {
+			case "uid", "channel_id", "id":
+				continue
+			}
+		}
+		if id == "" {
+			continue
+		}
+		err = ids.add(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids.list, nil
+}
+
+// idSet 去重并保持顺序的ID列表
+type idSet struct {
+	seen map[string]struct{}
+	list []string
+}
+
+func newIDSet() *idSet {
+	return &idSet{seen: make(map[string]struct{})}
+}
+
+func (s *idSet) add(ids ...string) error {
+	for _, id := range ids {
+		if _, ok := s.seen[id]; ok {
+			continue
+		}
+		if len(s.list) >= maxIDs {
+			return fmt.Errorf("too many ids, at most %d", maxIDs)
+		}
+		s.seen[id] = struct{}{}
+		s.list = append(s.list, id)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandIDRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		item    string
+		want    []string
+		wantErr string
+	}{
+		{name: "plain id", item: "u1", want: []string{"u1"}},
+		{name: "range", item: "usr[1-3]", want: []string{"usr1", "usr2", "usr3"}},
+		{name: "leading zeros kept", item: "usr[08-10]", want: []string{"usr08", "usr09", "usr10"}},
+		{name: "suffix", item: "g[1-2]x", want: []string{"g1x", "g2x"}},
+		{name: "several ranges", item: "g[1-2]u[1-2]", want: []string{"g1u1", "g1u2", "g2u1", "g2u2"}},
+		{name: "single value", item: "u[5-5]", want: []string{"u5"}},
+		{name: "unmatched [", item: "u[1-2", wantErr: "unmatched ["},
+		{name: "unmatched ]", item: "u1-2]", wantErr: "unmatched ]"},
+		{name: "not a range", item: "u[1]", wantErr: "expected [from-to]"},
+		{name: "reversed", item: "u[3-1]", wantErr: "0 <= from <= to"},
+		{name: "not a number", item: "u[a-b]", wantErr: "0 <= from <= to"},
+		{name: "negative", item: "u[-1-2]", wantErr: "0 <= from <= to"},
+		{name: "range too large", item: "u[0-10000000]", wantErr: "too large"},
+		{name: "product too large", item: "g[0-9999]u[0-9999]", wantErr: "expands to more than"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandIDRange(tt.item)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expandIDRange(%q) error = %v, want %q", tt.item, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandIDRange(%q) error = %v", tt.item, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandIDRange(%q) = %v, want %v", tt.item, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIDs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	lines := writeFile("ids.txt", "u1\n\n# comment\nu2\nu1\n")
+	csvFile := writeFile("ids.csv", "uid,token\nu1,t1\n u2 ,t2\n")
+	badCSV := writeFile("bad.csv", "u1\n\"u2\n")
+
+	tests := []struct {
+		name    string
+		spec    string
+		stdin   string
+		want    []string
+		wantErr string
+	}{
+		{name: "empty", spec: "  ", want: nil},
+		{name: "list", spec: "u1, u2,,u3", want: []string{"u1", "u2", "u3"}},
+		{name: "list with ranges", spec: "admin,u[1-2],u2", want: []string{"admin", "u1", "u2"}},
+		{name: "invalid range in list", spec: "u1,u[2-1]", wantErr: "invalid range"},
+		{name: "file", spec: "@" + lines, want: []string{"u1", "u2"}},
+		{name: "csv with header", spec: "@" + csvFile, want: []string{"u1", "u2"}},
+		{name: "invalid csv", spec: "@" + badCSV, wantErr: "read ids from"},
+		{name: "missing file", spec: "@" + filepath.Join(dir, "missing.txt"), wantErr: "no such file"},
+		{name: "stdin", spec: "-", stdin: "channel_id\ng1\ng2\n", want: []string{"g1", "g2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.stdin != "" {
+				stdin := os.Stdin
+				defer func() {
+					os.Stdin = stdin
+					stdinUsed = false
+				}()
+				os.Stdin, _ = os.Open(writeFile("stdin.txt", tt.stdin))
+				defer os.Stdin.Close()
+			}
+			got, err := parseIDs(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseIDs(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseIDs(%q) error = %v", tt.spec, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseIDs(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIDsStdinOnce(t *testing.T) {
+	defer func() { stdinUsed = false }()
+	stdinUsed = true
+	_, err := expandIDs([]string{"u1", "-"})
+	if err == nil || !strings.Contains(err.Error(), "only be used once") {
+		t.Fatalf("expandIDs() error = %v, want stdin used once", err)
+	}
+}
+
+func TestIDSetCap(t *testing.T) {
+	ids := newIDSet()
+	ids.list = make([]string, maxIDs)
+	if err := ids.add("u1"); err == nil {
+		t.Fatal("add() beyond maxIDs should fail")
+	}
+	// 重复的ID不计入数量
+	ids.seen["u0"] = struct{}{}
+	if err := ids.add("u0"); err != nil {
+		t.Fatalf("add() duplicate error = %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 操作日志中的记录类型
+const (
+	journalStart  = "start"  // 命令开始执行
+	journalOp     = "op"     // 一次修改数据的请求
+	journalEnd    = "end"    // 命令执行结束，之后的请求都已经记录
+	journalUndone = "undone" // 已经被撤销
+)
+
+// journalRecord 操作日志中的一行
+type journalRecord struct {
+	Type    string      `json:"type"`
+	Time    time.Time   `json:"time"`
+	RunID   string      `json:"run_id,omitempty"`  // start：本次执行的ID，undone：撤销时执行的ID
+	Context string      `json:"context,omitempty"` // start
+	User    string      `json:"user,omitempty"`    // start
+	Command string      `json:"command,omitempty"` // start
+	UndoOf  string      `json:"undo_of,omitempty"` // start：撤销的执行ID
+	Op      *auditEntry `json:"op,omitempty"`      // op
+}
+
+// runJournal 记录一次命令执行（一次运行）中所有修改数据的请求，用于 wk undo
+// 第一个请求时才创建文件（~/wukongim/.config/runs/<上下文>/<执行ID>.jsonl），命令正常结束时写入end
+type runJournal struct {
+	id      string
+	dir     string
+	context string
+	user    string
+	command string
+	undoOf  string // 本次执行是否为撤销
+
+	mu     sync.Mutex
+	file   *os.File
+	failed bool
+}
+
+func newRunJournal(contextName string, audit *auditLog) *runJournal {
+	return &runJournal{
+		id:      newRunID(time.Now()),
+		dir:     runsDir(contextName),
+		context: contextName,
+		user:    audit.user,
+		command: audit.command,
+	}
+}
+
+// newRunID 按时间排序的执行ID，比如 20240102-150405-1a2b
+func newRunID(now time.Time) string {
+	return fmt.Sprintf("%s-%04x", now.Format("20060102-150405"), rand.Intn(0x10000))
+}
+
+func runsDir(contextName string) string {
+	if contextName == "" {
+		contextName = noContextAudit
+	}
+	return filepath.Join(filepath.Dir(NewOptions().ContextDir()), "runs", contextName)
+}
+
+func (j *runJournal) path() string {
+	return filepath.Join(j.dir, j.id+".jsonl")
+}
+
+// record 记录一次修改数据的请求
+func (j *runJournal) record(entry *auditEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil && !j.failed {
+		err := j.open()
+		if err != nil {
+			j.failed = true
+			log.Printf("write run journal %s: %v", j.path(), err)
+		}
+	}
+	j.write(&journalRecord{Type: journalOp, Time: entry.Time, Op: entry})
+}
+
+func (j *runJournal) open() error {
+	err := os.MkdirAll(j.dir, 0700)
+	if err != nil {
+		return err
+	}
+	j.file, err = os.OpenFile(j.path(), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	j.write(&journalRecord{
+		Type:    journalStart,
+		Time:    time.Now(),
+		RunID:   j.id,
+		Context: j.context,
+		User:    j.user,
+		Command: j.command,
+		UndoOf:  j.undoOf,
+	})
+	return nil
+}
+
+func (j *runJournal) write(record *journalRecord) {
+	if j.file == nil || j.failed {
+		return
+	}
+	data, err := json.Marshal(record)
+	if err == nil {
+		_, err = j.file.Write(append(data, '\n'))
+	}
+	if err != nil {
+		j.failed = true
+		log.Printf("write run journal %s: %v", j.path(), err)
+	}
+}
+
+// finish 命令执行结束，所有请求都已经返回
+func (j *runJournal) finish() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return
+	}
+	j.write(&journalRecord{Type: journalEnd, Time: time.Now()})
+	j.file.Close()
+	j.file = nil
+}
+
+// journal 读取出的一次执行的操作日志
+type journal struct {
+	path     string
+	start    *journalRecord
+	ops      []*auditEntry
+	complete bool   // 是否有end记录
+	undoneBy string // 撤销时执行的ID
+}
+
+func loadJournal(path string) (*journal, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	j := &journal{path: path}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		record := &journalRecord{}
+		if json.Unmarshal(scanner.Bytes(), record) != nil {
+			continue // 写入中断的行
+		}
+		switch record.Type {
+		case journalStart:
+			j.start = record
+		case journalOp:
+			if record.Op != nil {
+				j.ops = append(j.ops, record.Op)
+			}
+		case journalEnd:
+			j.complete = true
+		case journalUndone:
+			j.undoneBy = record.RunID
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if j.start == nil {
+		return nil, fmt.Errorf("invalid run journal %s, missing start record", path)
+	}
+	return j, nil
+}
+
+// markUndone 记录该执行已经被runID撤销
+func (j *journal) markUndone(runID string) error {
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := json.Marshal(&journalRecord{Type: journalUndone, Time: time.Now(), RunID: runID})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// runIDs 上下文中所有执行的ID，从新到旧
+func runIDs(contextName string) ([]string, error) {
+	entries, err := os.ReadDir(runsDir(contextName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if name := entry.Name(); strings.HasSuffix(name, ".jsonl") {
+			ids = append(ids, strings.TrimSuffix(name, ".jsonl"))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
+	"github.com/spf13/cobra"
+)
+
+// membersFunc 添加或移除频道成员的请求
+type membersFunc func(ctx context.Context, api wkapi.Interface, ch Channel, uids []string) error
+
+type membersVar struct {
+	chType    int      // 频道类型
+	list      []string // 订阅者列表，原样使用
+	uids      string   // 订阅者ID来源
+	channels  string   // 频道ID来源，指定后忽略chPrefix和chNum
+	chPrefix  string   // 频道前缀
+	chNum     int      // 频道数量
+	subNum    int      // 订阅者数量
+	subPrefix string   // 订阅者前缀
+}
+
+// membersCMD 添加或移除频道成员的命令（subscriber、allowlist、denylist）
+type membersCMD struct {
+	ctx        *WuKongIMContext
+	api        wkapi.Interface
+	name       string // 命令名称
+	add        membersFunc
+	remove     membersFunc
+	membersVar *membersVar
+	bulkVar    *bulkVar
+}
+
+func newMembersCMD(ctx *WuKongIMContext, name string, add, remove membersFunc) *membersCMD {
+	s := &membersCMD{
+		ctx:        ctx,
+		name:       name,
+		add:        add,
+		remove:     remove,
+		membersVar: &membersVar{},
+		bulkVar:    &bulkVar{},
+	}
+	return s
+}
+
+func (s *membersCMD) CMD() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   s.name,
+		Short: s.name,
+	}
+
+	add := &cobra.Command{
+		Use:   "add",
+		Short: "add " + s.name,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return s.run(cmd, "add", s.add)
+		},
+	}
+
+	remove := &cobra.Command{
+		Use:   "remove",
+		Short: "remove " + s.name,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return s.run(cmd, "remove", s.remove)
+		},
+	}
+
+	cmd.AddCommand(mutating(add))
+	cmd.AddCommand(mutating(remove))
+
+	s.initMembersVar(add)
+	s.initMembersVar(remove)
+
+	return cmd
+}
+
+func (s *membersCMD) initMembersVar(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&s.membersVar.chPrefix, "chPrefix", "ch", "频道前缀")
+	cmd.Flags().IntVar(&s.membersVar.chType, "chType", 2, "频道类型")
+	cmd.Flags().StringVar(&s.membersVar.channels, "channels", "", "频道ID（"+idSourceUsage+"）")
+	cmd.Flags().StringSliceVar(&s.membersVar.list, "list", []string{}, "频道订阅者列表")
+	cmd.Flags().StringVar(&s.membersVar.uids, "uids", "", "频道订阅者ID（"+idSourceUsage+"），可以与 --list 一起使用")
+	cmd.Flags().StringVar(&s.membersVar.subPrefix, "subPrefix", "sub", "订阅者前缀")
+	cmd.Flags().IntVar(&s.membersVar.chNum, "chNum", 1, "频道数量")
+	cmd.Flags().IntVar(&s.membersVar.subNum, "subNum", 0, "订阅者数量")
+	s.bulkVar.initFlags(cmd)
+}
+
+func (s *membersCMD) run(cmd *cobra.Command, action string, request membersFunc) error {
+	api, err := s.ctx.newAPI()
+	if err != nil {
+		return err
+	}
+	s.api = api
+
+	log.Printf("Starting %s %s", s.name, action)
+
+	channels, err := s.channels()
+	if err != nil {
+		return err
+	}
+	subscribers, err := s.subscribers()
+	if err != nil {
+		return err
+	}
+
+	tasks := make([]*bulkTask, 0, len(channels))
+	for _, ch := range channels {
+		tasks = append(tasks, &bulkTask{
+			name:      ch.ChannelId,
+			channelId: ch.ChannelId,
+			uids:      subscribers,
+			run: func(ctx context.Context) error {
+				return request(ctx, s.api, ch, subscribers)
+			},
+		})
+	}
+	return s.ctx.runBulk(cmd, s.bulkVar, tasks)
+}
+
+// channels 要操作的频道
+func (s *membersCMD) channels() ([]Channel, error) {
+	channelIds, err := idsOrSequence(s.membersVar.channels, s.membersVar.chPrefix, s.membersVar.chNum)
+	if err != nil {
+		return nil, err
+	}
+	channels := make([]Channel, 0, len(channelIds))
+	for _, channelId := range channelIds {
+		channels = append(channels, Channel{
+			ChannelId:   channelId,
+			ChannelType: uint8(s.membersVar.chType),
+		})
+	}
+	return channels, nil
+}
+
+// subscribers 要添加或移除的用户：--list 中的uid原样使用，--uids 按ID来源展开，都没有指定时按 subPrefix 和 subNum 生成
+func (s *membersCMD) subscribers() ([]string, error) {
+	if len(s.membersVar.list) == 0 && strings.TrimSpace(s.membersVar.uids) == "" {
+		return idsOrSequence("", s.membersVar.subPrefix, s.membersVar.subNum)
+	}
+	ids := newIDSet()
+	err := ids.add(s.membersVar.list...)
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := parseIDs(s.membersVar.uids)
+	if err != nil {
+		return nil, err
+	}
+	err = ids.add(expanded...)
+	if err != nil {
+		return nil, err
+	}
+	return ids.list, nil
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMembersSubscribers(t *testing.T) {
+	tests := []struct {
+		name string
+		v    membersVar
+		want []string
+	}{
+		{"sequence", membersVar{subPrefix: "sub", subNum: 2}, []string{"sub0", "sub1"}},
+		// --list 中的uid原样使用，不按ID来源解析
+		{"literal list", membersVar{list: []string{"@bot", "-", "a[1]", "@bot"}, subNum: 2}, []string{"@bot", "-", "a[1]"}},
+		{"uids", membersVar{uids: "u[1-2],admin", subNum: 2}, []string{"u1", "u2", "admin"}},
+		{"list and uids", membersVar{list: []string{"a[1]", "u1"}, uids: "u[1-2]"}, []string{"a[1]", "u1", "u2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &membersCMD{membersVar: &tt.v}
+			got, err := s.subscribers()
+			if err != nil {
+				t.Fatalf("subscribers() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("subscribers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"log"
 	"math/rand"
-	"strconv"
 	"time"
 
 	"github.com/WuKongIM/WuKongIM/pkg/client"
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
 	wkproto "github.com/WuKongIM/WuKongIMGoProto"
 	"github.com/gosuri/uiprogress"
 	"github.com/panjf2000/ants/v2"
@@ -22,12 +23,13 @@ type mockVar struct {
 	chNum    int           // 频道数量
 	interval time.Duration // 发送消息间隔
 	duration time.Duration // 程序持续时间
-
+	uids     string        // 用户ID来源，指定后忽略prefix和num
+	channels string        // 频道ID来源，指定后忽略chPrefix和chNum
 }
 
 type mockCMD struct {
 	ctx           *WuKongIMContext
-	api           *API
+	api           wkapi.Interface
 	mockVar       *mockVar
 	userClientMap map[string]*testClient // 在线用户client
 	uids          []string               // 在线用户uids
@@ -38,7 +40,6 @@ type mockCMD struct {
 func newMockCMD(ctx *WuKongIMContext) *mockCMD {
 	s := &mockCMD{
 		ctx:           ctx,
-		api:           NewAPI(),
 		mockVar:       &mockVar{},
 		userClientMap: make(map[string]*testClient),
 	}
@@ -65,8 +66,8 @@ func (m *mockCMD) CMD() *cobra.Command {
 		RunE:  m.runChat,
 	}
 
-	cmd.AddCommand(online)
-	cmd.AddCommand(chat)
+	cmd.AddCommand(mutating(online))
+	cmd.AddCommand(mutating(chat))
 
 	m.initMockVar(online)
 	m.initMockVar(chat)
@@ -82,19 +83,50 @@ func (m *mockCMD) initMockVar(cmd *cobra.Command) {
 	cmd.Flags().IntVar(&m.mockVar.chType, "chType", 2, "channel type")
 	cmd.Flags().IntVar(&m.mockVar.chNum, "chNum", 1, "channel number")
 	cmd.Flags().DurationVar(&m.mockVar.interval, "interval", 5, "interval")
+	cmd.Flags().StringVar(&m.mockVar.uids, "uids", "", "user ids ("+idSourceUsage+")")
+	cmd.Flags().StringVar(&m.mockVar.channels, "channels", "", "channel ids ("+idSourceUsage+")")
+}
+
+// initIDs 生成在线用户和聊天频道
+func (m *mockCMD) initIDs() error {
+	uids, err := idsOrSequence(m.mockVar.uids, m.mockVar.prefix, m.mockVar.num)
+	if err != nil {
+		return err
+	}
+	m.uids = uids
+
+	if m.mockVar.channels == "" && m.mockVar.chPrefix == "" {
+		return nil
+	}
+	channelIds, err := idsOrSequence(m.mockVar.channels, m.mockVar.chPrefix, m.mockVar.chNum)
+	if err != nil {
+		return err
+	}
+	for _, channelId := range channelIds {
+		m.channels = append(m.channels, Channel{
+			ChannelId:   channelId,
+			ChannelType: uint8(m.mockVar.chType),
+		})
+	}
+	return nil
 }
 
 func (m *mockCMD) runOnline(cmd *cobra.Command, args []string) error {
+	err := m.initIDs()
+	if err != nil {
+		return err
+	}
 
 	// create progress bar
-	uiprogress.Start()
+	bars := m.ctx.out.progress()
+	bars.Start()
 
 	var progress *uiprogress.Bar
-	progressNum := m.mockVar.num
-	if m.mockVar.num <= 0 {
+	progressNum := len(m.uids)
+	if progressNum <= 0 {
 		progressNum = 1
 	}
-	progress = uiprogress.AddBar(progressNum).AppendCompleted().PrependElapsed()
+	progress = bars.AddBar(progressNum).AppendCompleted().PrependElapsed()
 
 	progress.Width = progressWidth()
 
@@ -107,11 +139,11 @@ func (m *mockCMD) runOnline(cmd *cobra.Command, args []string) error {
 	})
 
 	// online user
-	err := m.onlineUser(m.mockVar.num, func(cli *testClient) {
+	err = m.onlineUser(cmd.Context(), func(cli *testClient) {
 		progress.Incr()
 		if progress.Current() >= progress.Total {
 			state = "Done  "
-			uiprogress.Stop()
+			bars.Stop()
 		}
 	})
 	if err != nil {
@@ -136,19 +168,24 @@ func (m *mockCMD) runOnline(cmd *cobra.Command, args []string) error {
 
 func (m *mockCMD) runChat(cmd *cobra.Command, args []string) error {
 
-	if m.mockVar.num <= 1 {
+	err := m.initIDs()
+	if err != nil {
+		return err
+	}
+	if len(m.uids) <= 1 {
 		return errors.New("user number must be greater than 1")
 	}
 
 	// create progress bar
-	uiprogress.Start()
+	bars := m.ctx.out.progress()
+	bars.Start()
 
 	var progress *uiprogress.Bar
-	progressNum := m.mockVar.num
-	if m.mockVar.num <= 0 {
+	progressNum := len(m.uids)
+	if progressNum <= 0 {
 		progressNum = 1
 	}
-	progress = uiprogress.AddBar(progressNum).AppendCompleted().PrependElapsed()
+	progress = bars.AddBar(progressNum).AppendCompleted().PrependElapsed()
 
 	progress.Width = progressWidth()
 
@@ -161,11 +198,11 @@ func (m *mockCMD) runChat(cmd *cobra.Command, args []string) error {
 	})
 
 	// online user
-	err := m.onlineUser(m.mockVar.num, func(cli *testClient) {
+	err = m.onlineUser(cmd.Context(), func(cli *testClient) {
 		progress.Incr()
 		if progress.Current() >= progress.Total {
 			state = "Done  "
-			uiprogress.Stop()
+			bars.Stop()
 		}
 
 		cli.SetOnRecv(func(recv *wkproto.RecvPacket) error {
@@ -176,17 +213,6 @@ func (m *mockCMD) runChat(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// init channel if need
-	if m.mockVar.chPrefix != "" {
-		for i := 0; i < m.mockVar.chNum; i++ {
-			ch := Channel{
-				ChannelId:   m.mockVar.chPrefix + strconv.Itoa(i),
-				ChannelType: uint8(m.mockVar.chType),
-			}
-			m.channels = append(m.channels, ch)
-		}
-	}
-
 	m.runSender()
 
 	return nil
@@ -235,17 +261,16 @@ func (m *mockCMD) onRecvMessage(cli *testClient, recv *wkproto.RecvPacket) error
 	return nil
 }
 
-func (m *mockCMD) onlineUser(num int, callback func(cli *testClient)) error {
-	m.api.SetBaseURL(m.ctx.opts.ServerAddr)
-	// generate user id
-	m.uids = make([]string, num)
-	for i := 0; i < num; i++ {
-		m.uids[i] = m.mockVar.prefix + strconv.Itoa(i)
+func (m *mockCMD) onlineUser(ctx context.Context, callback func(cli *testClient)) error {
+	api, err := m.ctx.newAPI()
+	if err != nil {
+		return err
 	}
+	m.api = api
 	m.userClientMap = make(map[string]*testClient)
 
 	// get user tcp addr
-	userTcpAddrMap, err := m.api.Route(m.uids)
+	userTcpAddrMap, err := m.api.Route(ctx, m.uids)
 	if err != nil {
 		return err
 	}
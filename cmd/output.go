@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/gosuri/uiprogress"
+	terminal "golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// 输出格式
+const (
+	outputTable = "table" // 适合阅读的文本
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// 终端颜色
+const (
+	colorRed   = "\x1B[31m"
+	colorGreen = "\x1B[32m"
+	colorReset = "\x1b[0m"
+)
+
+// renderer 按 --output 输出命令的结果
+// 结果输出到标准输出，过程信息（日志、进度条）不会混入json和yaml结果中
+type renderer struct {
+	format string
+	out    io.Writer
+	tty    bool // 标准输出是否为终端
+}
+
+func newRenderer(format string) (*renderer, error) {
+	switch format {
+	case outputTable, outputJSON, outputYAML:
+	default:
+		return nil, fmt.Errorf("invalid output %q, must be %s, %s or %s", format, outputTable, outputJSON, outputYAML)
+	}
+	return &renderer{
+		format: format,
+		out:    os.Stdout,
+		tty:    terminal.IsTerminal(int(os.Stdout.Fd())),
+	}, nil
+}
+
+// structured 是否输出json或yaml
+func (r *renderer) structured() bool {
+	return r.format == outputJSON || r.format == outputYAML
+}
+
+// interactive 是否显示进度条和颜色：table格式并且输出到终端
+func (r *renderer) interactive() bool {
+	return !r.structured() && r.tty
+}
+
+// colored 给文本加上颜色，非交互输出时不加
+func (r *renderer) colored(color string, text string) string {
+	if !r.interactive() {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// progress 创建进度条容器，非交互输出时进度条不显示
+func (r *renderer) progress() *uiprogress.Progress {
+	p := uiprogress.New()
+	if r.interactive() {
+		p.SetOut(os.Stdout)
+	} else {
+		p.SetOut(ioutil.Discard)
+	}
+	return p
+}
+
+// render 输出结果，table格式时调用table，json和yaml格式时输出v
+func (r *renderer) render(v interface{}, table func(w io.Writer) error) error {
+	switch r.format {
+	case outputJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(r.out, string(data))
+		return err
+	case outputYAML:
+		data, err := toYAML(v)
+		if err != nil {
+			return err
+		}
+		_, err = r.out.Write(data)
+		return err
+	default:
+		return table(r.out)
+	}
+}
+
+// toYAML 按json tag输出yaml，保持字段顺序与json一致
+func toYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	err = yaml.Unmarshal(data, &node)
+	if err != nil {
+		return nil, err
+	}
+	resetYAMLStyle(&node)
+	var b strings.Builder
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	err = encoder.Encode(&node)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// resetYAMLStyle 去掉json的流式风格（{}、[]和引号），使用yaml的块风格输出
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
+	"github.com/WuKongIM/WuKongIMCli/pkg/network"
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
 	"github.com/spf13/cobra"
 )
 
@@ -11,23 +18,198 @@ var Version string
 var Commit string
 var CommitDate string
 
+// 用于临时覆盖上下文配置的环境变量
+const (
+	envContext = "WK_CONTEXT"
+	envServer  = "WK_SERVER"
+	envToken   = "WK_TOKEN"
+)
+
+type globalVar struct {
+	context string        // 本次执行使用的上下文名称
+	server  string        // 本次执行使用的服务地址
+	token   string        // 本次执行使用的token
+	timeout time.Duration // 单次http请求的超时时间
+	retries int           // http请求失败后的最大重试次数
+	verbose bool          // 输出每次http请求的方法、地址、状态码和耗时
+	trace   bool          // 同时输出http请求头、请求内容和响应内容
+	curl    bool          // 以curl命令的形式输出每次http请求
+	output  string        // 结果的输出格式 table、json 或 yaml
+}
+
 type WuKongIMContext struct {
-	opts *Options
-	w    *WuKongIM
+	opts        *Options
+	w           *WuKongIM
+	contextName string // 当前生效的上下文名称
+	confirmed   bool   // 是否已经确认在受保护的上下文中执行
+	globalVar   *globalVar
+	out         *renderer   // 按 --output 输出结果
+	audit       *auditLog   // 修改数据的请求写入审计日志
+	journal     *runJournal // 本次执行的操作日志，用于 wk undo
+	loadErr     error       // 加载当前上下文失败的原因，没有通过 --context 覆盖时才返回
 }
 
 func NewWuKongIMContext(w *WuKongIM) *WuKongIMContext {
 	c := &WuKongIMContext{
-		opts: NewOptions(),
-		w:    w,
+		opts:      NewOptions(),
+		w:         w,
+		globalVar: &globalVar{},
+	}
+	name, err := c.opts.Current()
+	if err != nil {
+		c.loadErr = fmt.Errorf("read the active context: %w", err)
+		return c
 	}
-	err := c.opts.Load()
+	c.contextName = name
+	if name == "" {
+		return c
+	}
+	err = c.opts.LoadContext(name)
 	if err != nil {
-		panic(err)
+		c.opts = NewOptions()
+		c.loadErr = fmt.Errorf("load the active context %q: %w", name, err)
 	}
 	return c
 }
 
+func (c *WuKongIMContext) initGlobalVar(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&c.globalVar.context, "context", "", "Context to use for this invocation, overrides the active one (env "+envContext+")")
+	cmd.PersistentFlags().StringVar(&c.globalVar.server, "server", "", "Http api server address for this invocation, comma separated for clusters (env "+envServer+")")
+	cmd.PersistentFlags().StringVar(&c.globalVar.token, "token", "", "Token for this invocation (env "+envToken+")")
+	cmd.PersistentFlags().DurationVar(&c.globalVar.timeout, "timeout", network.DefaultTransport.Timeout, "Timeout of each http request, 0 means no timeout")
+	cmd.PersistentFlags().IntVar(&c.globalVar.retries, "retries", network.DefaultTransport.Retries, "Max retries of a failed http request (only for requests that are safe to repeat)")
+	cmd.PersistentFlags().BoolVarP(&c.globalVar.verbose, "verbose", "v", false, "Log every http request: method, URL, status and latency (to stderr)")
+	cmd.PersistentFlags().BoolVar(&c.globalVar.trace, "trace", false, "Like --verbose, plus request headers (secrets redacted), request and response bodies")
+	cmd.PersistentFlags().StringVarP(&c.globalVar.output, "output", "o", outputTable, "Output format: table, json or yaml; progress bars and colors are only shown for table on a terminal")
+	cmd.PersistentFlags().BoolVar(&c.globalVar.curl, "curl", false, "Print every http request as an equivalent curl command (to stderr, secrets redacted)")
+}
+
+// resolveOptions 按优先级确定本次执行的配置：命令行参数 > 环境变量 > 当前上下文
+func (c *WuKongIMContext) resolveOptions(cmd *cobra.Command, args []string) error {
+	// 参数解析通过后，执行中的错误（比如API错误）不再打印用法
+	cmd.SilenceUsage = true
+
+	name := firstNonEmpty(c.globalVar.context, os.Getenv(envContext))
+	if name == "" && c.loadErr != nil && !managesContexts(cmd) {
+		return fmt.Errorf("%w, select another one with `wk context use <name>` or --context", c.loadErr)
+	}
+	if name != "" && (name != c.contextName || c.loadErr != nil) {
+		opts := NewOptions()
+		err := opts.LoadContext(name)
+		if err != nil {
+			return err
+		}
+		c.opts = opts
+		c.contextName = name
+	}
+	token := firstNonEmpty(c.globalVar.token, os.Getenv(envToken))
+	if server := firstNonEmpty(c.globalVar.server, os.Getenv(envServer)); server != "" {
+		endpoints := c.opts.Endpoints()
+		c.opts.SetServers(server)
+		// 上下文的token和请求头只发送给上下文的服务地址，其它地址需要通过 --token 指定
+		if token == "" && !sameEndpoints(endpoints, c.opts.Endpoints()) {
+			if c.opts.Token != "" || c.opts.encryptedToken != "" || len(c.opts.Headers) > 0 {
+				fmt.Fprintf(os.Stderr, "Not sending the token and headers of %s to %s, use --token to authenticate\n", c.target(), server)
+			}
+			c.opts.Token = ""
+			c.opts.TokenEncrypted = false
+			c.opts.encryptedToken = ""
+			c.opts.Headers = nil
+		}
+	}
+	if token != "" {
+		c.opts.Token = token
+	}
+
+	// 参数默认值：上下文配置优先于全局配置
+	cfg, err := loadCLIConfig()
+	if err != nil {
+		return err
+	}
+	err = applyFlagDefaults(cmd, c.opts.Defaults, cfg.Defaults)
+	if err != nil {
+		return err
+	}
+	if c.globalVar.retries < 0 {
+		return fmt.Errorf("invalid --retries %d, must not be negative", c.globalVar.retries)
+	}
+	network.DefaultTransport.Timeout = c.globalVar.timeout
+	network.DefaultTransport.Retries = c.globalVar.retries
+	network.DefaultTransport.Tracer = c.tracer()
+
+	c.out, err = newRenderer(c.globalVar.output)
+	if err != nil {
+		return err
+	}
+	c.audit = newAuditLog(c.contextName, append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...))
+	c.journal = newRunJournal(c.contextName, c.audit)
+	if isMutating(cmd) {
+		return c.checkMutation(cmd)
+	}
+	return nil
+}
+
+// tracer 根据 --verbose、--trace 和 --curl 创建请求跟踪，token和上下文中的请求头会被打码
+func (c *WuKongIMContext) tracer() *network.Tracer {
+	level := network.TraceOff
+	switch {
+	case c.globalVar.trace:
+		level = network.TraceFull
+	case c.globalVar.verbose:
+		level = network.TraceBasic
+	}
+	if level == network.TraceOff && !c.globalVar.curl {
+		return nil
+	}
+	redactHeaders := []string{wkapi.TokenHeader}
+	for name := range c.opts.Headers {
+		redactHeaders = append(redactHeaders, name)
+	}
+	return &network.Tracer{
+		Out:           os.Stderr,
+		Level:         level,
+		Curl:          c.globalVar.curl,
+		RedactHeaders: redactHeaders,
+		RedactFields:  []string{"token"}, // 用户token（/user/token）
+	}
+}
+
+// managesContexts 是否是 wk context 的子命令，当前上下文无法加载时也可以执行（比如切换或删除上下文）
+func managesContexts(cmd *cobra.Command) bool {
+	for ; cmd != nil && cmd.HasParent(); cmd = cmd.Parent() {
+		if cmd.Name() == "context" && !cmd.Parent().HasParent() {
+			return true
+		}
+	}
+	return false
+}
+
+// sameEndpoints 两组服务地址是否相同（不区分顺序）
+func sameEndpoints(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	addrs := make(map[string]bool, len(a))
+	for _, addr := range a {
+		addrs[addr] = true
+	}
+	for _, addr := range b {
+		if !addrs[addr] {
+			return false
+		}
+	}
+	return true
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 type WuKongIM struct {
 	rootCmd *cobra.Command
 }
@@ -42,6 +224,7 @@ func NewWuKongIM() *WuKongIM {
 			CompletionOptions: cobra.CompletionOptions{
 				DisableDefaultCmd: true,
 			},
+			SilenceErrors: true, // 错误由Execute统一输出
 		},
 	}
 }
@@ -52,6 +235,8 @@ func (l *WuKongIM) addCommand(cmd CMD) {
 
 func (l *WuKongIM) Execute() {
 	ctx := NewWuKongIMContext(l)
+	ctx.initGlobalVar(l.rootCmd)
+	l.rootCmd.PersistentPreRunE = ctx.resolveOptions
 	l.addCommand(newContextCMD(ctx))    // 上下文命令
 	l.addCommand(newBenchCMD(ctx))      // 压力测试命令
 	l.addCommand(newTopCMD(ctx))        // top命令
@@ -64,9 +249,20 @@ func (l *WuKongIM) Execute() {
 	l.addCommand(newUserCMD(ctx))       // 用户命令
 	l.addCommand(newDenylistCMD(ctx))   // 黑名单命令
 	l.addCommand(newAllowlistCMD(ctx))  // 白名单命令
+	l.addCommand(newConfigCMD(ctx))     // 配置命令
+	l.addCommand(newAPICMD(ctx))        // 调用任意http api
+	l.addCommand(newAuditCMD(ctx))      // 审计日志
+	l.addCommand(newUndoCMD(ctx))       // 撤销批量操作
 
-	if err := l.rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	// Ctrl+C 取消正在进行的请求
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := l.rootCmd.ExecuteContext(signalCtx)
+	stop()
+	if ctx.journal != nil {
+		ctx.journal.finish()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkutil"
+	terminal "golang.org/x/term"
+)
+
+// 提供密码的环境变量，未设置时从终端读取
+const (
+	envPassphrase       = "WK_PASSPHRASE"        // 加密保存的上下文token的密码
+	envBundlePassphrase = "WK_BUNDLE_PASSPHRASE" // 上下文导出包的密码
+	envTokenSecret      = "WK_TOKEN_SECRET"      // 生成用户token（--token-mode hmac）的密钥
+)
+
+// contextBundleVersion 上下文导出包的格式版本
+const contextBundleVersion = 1
+
+// contextBundle 上下文导出包，contexts为加密后的上下文集合（上下文名称 -> 上下文内容）
+type contextBundle struct {
+	Version  int    `json:"version"`
+	Contexts string `json:"contexts"`
+}
+
+// readPassphrase 读取密码，优先使用环境变量，confirm为true时需要输入两次
+func readPassphrase(env string, prompt string, confirm bool) (string, error) {
+	if passphrase := os.Getenv(env); passphrase != "" {
+		return passphrase, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return "", fmt.Errorf("a passphrase is required, set %s when not running in a terminal", env)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(passphrase) == 0 {
+		return "", errors.New("passphrase can not be empty")
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		again, err := terminal.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(again) != string(passphrase) {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return string(passphrase), nil
+}
+
+func encodeContextBundle(contexts map[string]map[string]interface{}, passphrase string) ([]byte, error) {
+	data, err := json.Marshal(contexts)
+	if err != nil {
+		return nil, err
+	}
+	crypted, err := wkutil.AesEncryptWithPassphrase(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(&contextBundle{
+		Version:  contextBundleVersion,
+		Contexts: crypted,
+	}, "", "  ")
+}
+
+func decodeContextBundle(data []byte, passphrase string) (map[string]map[string]interface{}, error) {
+	var bundle contextBundle
+	err := json.Unmarshal(data, &bundle)
+	if err != nil {
+		return nil, fmt.Errorf("invalid context bundle: %w", err)
+	}
+	if bundle.Version != contextBundleVersion {
+		return nil, fmt.Errorf("unsupported context bundle version %d", bundle.Version)
+	}
+	plain, err := wkutil.AesDecryptWithPassphrase(bundle.Contexts, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var contexts map[string]map[string]interface{}
+	err = json.Unmarshal(plain, &contexts)
+	if err != nil {
+		return nil, err
+	}
+	return contexts, nil
+}
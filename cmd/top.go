@@ -1,14 +1,16 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
 	"github.com/gizak/termui/v3"
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
@@ -16,8 +18,8 @@ import (
 )
 
 type Stats struct {
-	Varz  *Varz
-	Connz *Connz
+	Varz  *wkapi.Varz
+	Connz *wkapi.Connz
 	Rates *Rates
 	Error error
 }
@@ -25,7 +27,8 @@ type Stats struct {
 type topCMD struct {
 	ctx          *WuKongIMContext
 	urlStr       string
-	httpClient   *http.Client
+	endpoint     string          // 监控的节点，集群时可指定地址或序号
+	api          wkapi.Interface // 监控节点的api
 	limit        int
 	sortOpt      SortOpt
 	delay        int
@@ -36,6 +39,7 @@ type topCMD struct {
 
 	displayRawBytes bool
 	displayClients  bool // 是否展示客户端
+	once            bool // 只输出一次统计信息后退出
 
 	maxStatsRefreshes int // 最大刷新次数 -1 表示无限制
 	body              *ui.Grid
@@ -44,7 +48,6 @@ type topCMD struct {
 func newTopCMD(ctx *WuKongIMContext) *topCMD {
 	b := &topCMD{
 		ctx:               ctx,
-		httpClient:        &http.Client{},
 		delay:             1,
 		statsCh:           make(chan *Stats),
 		shutdownCh:        make(chan struct{}),
@@ -64,17 +67,22 @@ func (t *topCMD) CMD() *cobra.Command {
 }
 
 func (t *topCMD) initVar(cmd *cobra.Command) {
-
+	cmd.Flags().StringVar(&t.endpoint, "endpoint", "", "Node to monitor, a server URL or its index in the context (default the first reachable)")
+	cmd.Flags().BoolVar(&t.once, "once", false, "Print the stats once and exit instead of the interactive view, rates are sampled over one second")
 }
 
 func (t *topCMD) run(cmd *cobra.Command, args []string) error {
 
 	// Smoke test to abort in case can't connect to server since the beginning.
-	_, err := t.request("/varz")
+	err := t.selectEndpoint()
 	if err != nil {
 		return err
 	}
 
+	if t.once {
+		return t.printOnce()
+	}
+
 	err = ui.Init()
 	if err != nil {
 		panic(err)
@@ -88,38 +96,95 @@ func (t *topCMD) run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// selectEndpoint 确定要监控的节点，未指定时使用第一个可访问的节点
+func (t *topCMD) selectEndpoint() error {
+	endpoints := t.ctx.opts.Endpoints()
+	if t.endpoint != "" {
+		urlStr := t.endpoint
+		if i, err := strconv.Atoi(t.endpoint); err == nil {
+			if i < 0 || i >= len(endpoints) {
+				return fmt.Errorf("endpoint index %d out of range, the context has %d servers", i, len(endpoints))
+			}
+			urlStr = endpoints[i]
+		}
+		return t.useEndpoint(urlStr)
+	}
+	if len(endpoints) == 0 {
+		return errors.New("no server address configured")
+	}
+	var err error
+	for _, endpoint := range endpoints {
+		if err = t.useEndpoint(endpoint); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// useEndpoint 监控指定的节点，节点无法访问时返回错误
+func (t *topCMD) useEndpoint(urlStr string) error {
+	api, err := t.ctx.newAPI(wkapi.WithEndpoints(urlStr))
+	if err != nil {
+		return err
+	}
+	t.urlStr = urlStr
+	t.api = api
+	_, err = t.request("/varz")
+	return err
+}
+
 func (t *topCMD) request(path string) (interface{}, error) {
-	var statz interface{}
-	uri := t.ctx.opts.ServerAddr + path
+	var (
+		statz interface{}
+		err   error
+	)
 	switch path {
 	case "/varz":
-		statz = &Varz{}
+		statz, err = t.api.Varz(context.Background())
 	case "/connz":
-		statz = &Connz{}
-		uri += fmt.Sprintf("?limit=%d&sort=%s", t.limit, t.sortOpt)
+		statz, err = t.api.Connz(context.Background(), &wkapi.ConnzReq{
+			Limit: t.limit,
+			Sort:  string(t.sortOpt),
+		})
 	default:
 		return nil, fmt.Errorf("invalid path '%s' for stats server", path)
 	}
-	resp, err := t.httpClient.Get(uri)
-	if resp != nil {
-		defer resp.Body.Close()
-	}
 	if err != nil {
 		return nil, fmt.Errorf("could not get stats from server: %w", err)
 	}
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("could not read response body: %w", err)
-	}
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("stats request failed %d: %q", resp.StatusCode, string(body))
+	return statz, nil
+}
+
+// topSnapshot top --once 输出的结果
+type topSnapshot struct {
+	Endpoint string       `json:"endpoint"`
+	Varz     *wkapi.Varz  `json:"varz"`
+	Rates    *Rates       `json:"rates"`
+	Connz    *wkapi.Connz `json:"connz"`
+}
+
+// printOnce 间隔delay采样两次（用于计算速率），输出后退出
+func (t *topCMD) printOnce() error {
+	var stats *Stats
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			time.Sleep(time.Duration(t.delay) * time.Second)
+		}
+		stats = t.fetchStats()
+		if stats.Error != errDud {
+			return stats.Error
+		}
 	}
-	err = json.Unmarshal(body, &statz)
-	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal statz json: %w", err)
+	snapshot := &topSnapshot{
+		Endpoint: t.urlStr,
+		Varz:     stats.Varz,
+		Rates:    stats.Rates,
+		Connz:    stats.Connz,
 	}
-
-	return statz, nil
+	return t.ctx.out.render(snapshot, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, t.generateParagraphPlainText(stats))
+		return err
+	})
 }
 
 func (t *topCMD) monitorStats() error {
@@ -153,8 +218,8 @@ func (t *topCMD) fetchStats() *Stats {
 	var outBytesRate float64
 
 	stats := &Stats{
-		Varz:  &Varz{},
-		Connz: &Connz{},
+		Varz:  &wkapi.Varz{},
+		Connz: &wkapi.Connz{},
 		Rates: &Rates{},
 		Error: errDud,
 	}
@@ -166,7 +231,7 @@ func (t *topCMD) fetchStats() *Stats {
 			return stats
 		}
 
-		if varz, ok := result.(*Varz); ok {
+		if varz, ok := result.(*wkapi.Varz); ok {
 			stats.Varz = varz
 		}
 	}
@@ -178,7 +243,7 @@ func (t *topCMD) fetchStats() *Stats {
 			return stats
 		}
 
-		if connz, ok := result.(*Connz); ok {
+		if connz, ok := result.(*wkapi.Connz); ok {
 			stats.Connz = connz
 		}
 	}
@@ -236,8 +301,8 @@ func (t *topCMD) fetchStats() *Stats {
 
 func (t *topCMD) StartUI() {
 	cleanStats := &Stats{
-		Varz:  &Varz{},
-		Connz: &Connz{},
+		Varz:  &wkapi.Varz{},
+		Connz: &wkapi.Connz{},
 		Rates: &Rates{},
 		Error: fmt.Errorf(""),
 	}
@@ -587,37 +652,11 @@ func (t *topCMD) generateParagraphPlainText(
 	return text
 }
 
-type Connz struct {
-	Connections []*ConnInfo `json:"connections"` // 连接数
-	Now         time.Time   `json:"now"`         // 查询时间
-	Total       int         `json:"total"`       // 总连接数量
-	Offset      int         `json:"offset"`      // 偏移位置
-	Limit       int         `json:"limit"`       // 限制数量
-}
-
-type ConnInfo struct {
-	ID           int64     `json:"id"`            // 连接ID
-	UID          string    `json:"uid"`           // 用户uid
-	IP           string    `json:"ip"`            // 客户端IP
-	Port         int       `json:"port"`          // 客户端端口
-	LastActivity time.Time `json:"last_activity"` // 最后一次活动时间
-	Uptime       string    `json:"uptime"`        // 启动时间
-	Idle         string    `json:"idle"`          // 客户端闲置时间
-	PendingBytes int       `json:"pending_bytes"` // 等待发送的字节数
-	InMsgs       int64     `json:"in_msgs"`       // 流入的消息数
-	OutMsgs      int64     `json:"out_msgs"`      // 流出的消息数量
-	InBytes      int64     `json:"in_bytes"`      // 流入的字节数量
-	OutBytes     int64     `json:"out_bytes"`     // 流出的字节数量
-	Device       string    `json:"device"`        // 设备
-	DeviceID     string    `json:"device_id"`     // 设备ID
-	Version      uint8     `json:"version"`       // 客户端协议版本
-}
-
 type Rates struct {
-	InMsgsRate   float64
-	OutMsgsRate  float64
-	InBytesRate  float64
-	OutBytesRate float64
+	InMsgsRate   float64 `json:"in_msgs_rate"`
+	OutMsgsRate  float64 `json:"out_msgs_rate"`
+	InBytesRate  float64 `json:"in_bytes_rate"`
+	OutBytesRate float64 `json:"out_bytes_rate"`
 }
 
 type SortOpt string
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
+	"github.com/spf13/cobra"
+)
+
+// undoOp 可以撤销的请求
+type undoOp struct {
+	inverse string // 相反操作的名称
+	apply   func(ctx context.Context, api wkapi.Interface, op *auditEntry) error
+}
+
+// undoOps 请求路径 -> 相反的操作
+var undoOps = map[string]*undoOp{
+	"/channel/subscriber_add": {
+		inverse: "subscriber remove",
+		apply: func(ctx context.Context, api wkapi.Interface, op *auditEntry) error {
+			return api.SubscriberRemove(ctx, &wkapi.SubscriberReq{ChannelId: op.ChannelId, ChannelType: op.ChannelType, Subscribers: op.Uids})
+		},
+	},
+	"/channel/subscriber_remove": {
+		inverse: "subscriber add",
+		apply: func(ctx context.Context, api wkapi.Interface, op *auditEntry) error {
+			return api.SubscriberAdd(ctx, &wkapi.SubscriberAddReq{ChannelId: op.ChannelId, ChannelType: op.ChannelType, Subscribers: op.Uids})
+		},
+	},
+	"/channel/blacklist_add": {
+		inverse: "denylist remove",
+		apply: func(ctx context.Context, api wkapi.Interface, op *auditEntry) error {
+			return api.DenylistRemove(ctx, &wkapi.ChannelUidsReq{ChannelId: op.ChannelId, ChannelType: op.ChannelType, Uids: op.Uids})
+		},
+	},
+	"/channel/blacklist_remove": {
+		inverse: "denylist add",
+		apply: func(ctx context.Context, api wkapi.Interface, op *auditEntry) error {
+			return api.DenylistAdd(ctx, &wkapi.ChannelUidsReq{ChannelId: op.ChannelId, ChannelType: op.ChannelType, Uids: op.Uids})
+		},
+	},
+	"/channel/whitelist_add": {
+		inverse: "allowlist remove",
+		apply: func(ctx context.Context, api wkapi.Interface, op *auditEntry) error {
+			return api.AllowlistRemove(ctx, &wkapi.ChannelUidsReq{ChannelId: op.ChannelId, ChannelType: op.ChannelType, Uids: op.Uids})
+		},
+	},
+	"/channel/whitelist_remove": {
+		inverse: "allowlist add",
+		apply: func(ctx context.Context, api wkapi.Interface, op *auditEntry) error {
+			return api.AllowlistAdd(ctx, &wkapi.ChannelUidsReq{ChannelId: op.ChannelId, ChannelType: op.ChannelType, Uids: op.Uids})
+		},
+	},
+}
+
+type undoCMD struct {
+	ctx     *WuKongIMContext
+	api     wkapi.Interface
+	bulkVar *bulkVar
+}
+
+func newUndoCMD(ctx *WuKongIMContext) *undoCMD {
+	return &undoCMD{
+		ctx:     ctx,
+		bulkVar: &bulkVar{},
+	}
+}
+
+func (u *undoCMD) CMD() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo [run-id]",
+		Short: "Reverse the membership changes (subscriber, allowlist, denylist) of a previous run, defaults to the latest one",
+		Example: `  wk undo --dry-run
+  wk undo 20240102-150405-1a2b`,
+		RunE: u.run,
+	}
+	u.bulkVar.initFlags(cmd)
+	// 撤销前总是需要确认
+	cmd.Flags().Lookup("confirm-threshold").DefValue = "0"
+	u.bulkVar.confirmAbove = 0
+	return mutating(cmd)
+}
+
+func (u *undoCMD) run(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		cmd.Help()
+		return nil
+	}
+	j, err := u.selectJournal(args)
+	if err != nil {
+		return err
+	}
+	if !j.complete {
+		return fmt.Errorf("run %s did not finish (it was killed or crashed), its journal may miss requests that reached the server, refusing to undo", j.start.RunID)
+	}
+	if j.undoneBy != "" {
+		return fmt.Errorf("run %s was already undone by run %s", j.start.RunID, j.undoneBy)
+	}
+
+	// 重置订阅者时删除的原有成员没有记录，无法恢复
+	for _, op := range j.ops {
+		if op.OK && op.Reset {
+			return fmt.Errorf("run %s reset the subscribers of channel %s, the members it removed were not recorded, refusing to undo", j.start.RunID, op.ChannelId)
+		}
+	}
+	undoable, skipped := undoableOps(j)
+	if len(undoable) == 0 {
+		return fmt.Errorf("run %s (%s) has no membership changes that can be undone", j.start.RunID, j.start.Command)
+	}
+
+	api, err := u.ctx.newAPI()
+	if err != nil {
+		return err
+	}
+	u.api = api
+	u.ctx.journal.undoOf = j.start.RunID
+
+	// 需要确认时总是输出预览（输出到stderr，不影响json和yaml的输出）
+	if !u.bulkVar.dryRun && (!u.bulkVar.yes || !u.ctx.out.structured()) {
+		u.printPreview(os.Stderr, j, undoable, skipped)
+	}
+
+	// 按相反的顺序撤销
+	tasks := make([]*bulkTask, 0, len(undoable))
+	for i := len(undoable) - 1; i >= 0; i-- {
+		op := undoable[i]
+		tasks = append(tasks, &bulkTask{
+			name:      fmt.Sprintf("%s %s", undoOps[op.Path].inverse, op.ChannelId),
+			channelId: op.ChannelId,
+			uids:      op.Uids,
+			run: func(ctx context.Context) error {
+				return undoOps[op.Path].apply(ctx, u.api, op)
+			},
+		})
+	}
+	err = u.ctx.runBulk(cmd, u.bulkVar, tasks)
+	if err != nil || u.bulkVar.dryRun {
+		return err
+	}
+	return j.markUndone(u.ctx.journal.id)
+}
+
+// selectJournal 指定的执行，或者最近一次没有被撤销、也不是撤销的执行
+func (u *undoCMD) selectJournal(args []string) (*journal, error) {
+	dir := runsDir(u.ctx.contextName)
+	if len(args) == 1 {
+		if !validName(args[0]) {
+			return nil, fmt.Errorf("invalid run id %q", args[0])
+		}
+		j, err := loadJournal(filepath.Join(dir, args[0]+".jsonl"))
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("unknown run %q in %s", args[0], u.ctx.target())
+		}
+		return j, err
+	}
+	ids, err := runIDs(u.ctx.contextName)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		j, err := loadJournal(filepath.Join(dir, id+".jsonl"))
+		if err != nil {
+			return nil, err
+		}
+		if j.start.UndoOf != "" || j.undoneBy != "" {
+			continue
+		}
+		if ops, _ := undoableOps(j); len(ops) > 0 || !j.complete {
+			return j, nil
+		}
+	}
+	return nil, errors.New("no run with membership changes to undo")
+}
+
+// undoableOps 可以撤销的请求（成功的订阅者、黑白名单修改），以及不会被撤销的请求
+func undoableOps(j *journal) ([]*auditEntry, []*auditEntry) {
+	var undoable, skipped []*auditEntry
+	for _, op := range j.ops {
+		if op.OK && undoOps[op.Path] != nil && op.ChannelId != "" && len(op.Uids) > 0 {
+			undoable = append(undoable, op)
+		} else {
+			skipped = append(skipped, op)
+		}
+	}
+	return undoable, skipped
+}
+
+// printPreview 输出要执行的相反操作
+func (u *undoCMD) printPreview(out io.Writer, j *journal, undoable, skipped []*auditEntry) {
+	fmt.Fprintf(out, "Undo run %s by %s at %s:\n  %s\n\n", j.start.RunID, j.start.User, j.start.Time.Local().Format("2006-01-02 15:04:05"), j.start.Command)
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "UNDO\tWITH\tCHANNEL\tUIDS")
+	for i := len(undoable) - 1; i >= 0; i-- {
+		op := undoable[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", strings.TrimPrefix(op.Path, "/channel/"), undoOps[op.Path].inverse, op.ChannelId, summarizeUids(op.Uids))
+	}
+	w.Flush()
+	for _, op := range undoable {
+		if strings.HasSuffix(op.Path, "_remove") {
+			fmt.Fprintln(out, "\nWarning: removing is recorded without the previous members, undoing a remove adds every uid of the request, including uids that were not members before it.")
+			break
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(out, "\n%d requests of the run will not be undone (failed or not a membership change):\n", len(skipped))
+		for _, op := range skipped {
+			result := "ok"
+			if !op.OK {
+				result = "failed: " + op.Error
+			}
+			fmt.Fprintf(out, "  %s %s %s (%s)\n", op.Method, op.Path, op.ChannelId, result)
+		}
+	}
+	fmt.Fprintln(out)
+}
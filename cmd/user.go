@@ -1,30 +1,41 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 
-	wkproto "github.com/WuKongIM/WuKongIMGoProto"
-	"github.com/gosuri/uiprogress"
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
 	"github.com/spf13/cobra"
 )
 
 type userVar struct {
 	num    int    // 数量
 	prefix string // 用户前缀
+	uids   string // 用户ID来源，指定后忽略prefix和num
+
+	kickDevice string // 强制下线的设备
+	kickDryRun bool   // 只输出强制下线的请求，不发送
 }
 
 type userCMD struct {
-	ctx     *WuKongIMContext
-	api     *API
-	userVar *userVar
+	ctx       *WuKongIMContext
+	api       wkapi.Interface
+	userVar   *userVar
+	tokenVar  *tokenVar
+	importVar *importVar
+	systemVar *systemVar
+	bulkVar   *bulkVar
 }
 
 func newUserCMD(ctx *WuKongIMContext) *userCMD {
 	u := &userCMD{
-		ctx:     ctx,
-		api:     NewAPI(),
-		userVar: &userVar{},
+		ctx:       ctx,
+		userVar:   &userVar{},
+		tokenVar:  &tokenVar{},
+		importVar: &importVar{},
+		systemVar: &systemVar{},
+		bulkVar:   &bulkVar{},
 	}
 	return u
 }
@@ -38,49 +49,169 @@ func (u *userCMD) CMD() *cobra.Command {
 	create := &cobra.Command{
 		Use:   "create",
 		Short: "create user",
-		RunE:  u.runCreate,
+		Example: `  wk user create --num 1000 --token-mode random --credentials users.csv
+  wk user create --uids usr[1-100] --device-flag app,web --device-level master`,
+		RunE: u.runCreate,
 	}
 
-	cmd.AddCommand(create)
+	cmd.AddCommand(mutating(create))
 
 	u.initCreateVar(create)
 
+	token := &cobra.Command{
+		Use:   "token",
+		Short: "user token",
+	}
+	tokenSet := &cobra.Command{
+		Use:   "set <uids...>",
+		Short: "Set the token of users on the given devices",
+		Example: `  wk user token set usr1 --user-token abc --device-flag web
+  WK_TOKEN_SECRET=s3cret wk user token set usr[1-100] --token-mode hmac --credentials users.json`,
+		RunE: u.runTokenSet,
+	}
+	u.tokenVar.initFlags(tokenSet)
+	u.bulkVar.initFlags(tokenSet)
+	token.AddCommand(mutating(tokenSet))
+	cmd.AddCommand(token)
+
+	importCMD := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import users (uid, token, device_flag, device_level) from a CSV or JSONL file",
+		Example: `  wk user import users.csv
+  wk user import users.jsonl --continue-on-error --concurrency 20`,
+		RunE: u.runImport,
+	}
+	u.initImportVar(importCMD)
+	cmd.AddCommand(mutating(importCMD))
+
+	status := &cobra.Command{
+		Use:   "status <uids...>",
+		Short: "Show whether users are online and on which devices",
+		Example: `  wk user status usr1 usr2
+  wk user status usr[1-100] -o json`,
+		RunE: u.runStatus,
+	}
+	cmd.AddCommand(status)
+
+	kick := &cobra.Command{
+		Use:   "kick <uid>",
+		Short: "Force a device of the user offline",
+		Example: `  wk user kick usr1 --device-flag web
+  wk user kick usr1 --dry-run`,
+		RunE: u.runKick,
+	}
+	kick.Flags().StringVar(&u.userVar.kickDevice, "device-flag", "all", "Device to force offline: app, web, pc or all")
+	kick.Flags().BoolVar(&u.userVar.kickDryRun, "dry-run", false, "Print the request without sending it")
+	cmd.AddCommand(mutating(kick))
+
+	cmd.AddCommand(u.systemCMD())
+
+	sessions := &cobra.Command{
+		Use:   "sessions <uid>",
+		Short: "List the live connections of a user across the cluster",
+		Example: `  wk user sessions usr1
+  wk user sessions usr1 -o json`,
+		RunE: u.runSessions,
+	}
+	cmd.AddCommand(sessions)
+
 	return cmd
 }
 func (u *userCMD) initCreateVar(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&u.userVar.prefix, "prefix", "usr", "用户前缀")
 	cmd.Flags().IntVar(&u.userVar.num, "num", 0, "用户数量")
+	cmd.Flags().StringVar(&u.userVar.uids, "uids", "", "用户ID（"+idSourceUsage+"）")
+	u.tokenVar.initFlags(cmd)
+	u.bulkVar.initFlags(cmd)
 }
 
 func (u *userCMD) runCreate(cmd *cobra.Command, args []string) error {
-	u.api.SetBaseURL(u.ctx.opts.ServerAddr)
+	uids, err := idsOrSequence(u.userVar.uids, u.userVar.prefix, u.userVar.num)
+	if err != nil {
+		return err
+	}
+	log.Printf("Starting create user, num: %d", len(uids))
 
-	uiprogress.Start()
-	defer uiprogress.Stop()
-	var progress *uiprogress.Bar
-	progressNum := u.userVar.num
-	if u.userVar.num <= 0 {
-		progressNum = 1
+	// 创建用户
+	return u.setTokens(cmd, uids)
+}
+
+func (u *userCMD) runTokenSet(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		cmd.Help()
+		return nil
+	}
+	uids, err := expandIDs(args)
+	if err != nil {
+		return err
 	}
-	progress = uiprogress.AddBar(progressNum).AppendCompleted().PrependElapsed()
-	progress.Width = progressWidth()
-	log.Printf("Starting create user, num: %d", u.userVar.num)
+	return u.setTokens(cmd, uids)
+}
 
-	state := "Setup     "
-	progress.PrependFunc(func(b *uiprogress.Bar) string {
-		return state
-	})
+// setTokens 为每个用户的每个设备设置token，设置成功的写入 --credentials
+func (u *userCMD) setTokens(cmd *cobra.Command, uids []string) error {
+	err := u.tokenVar.check()
+	if err != nil {
+		return err
+	}
+	api, err := u.ctx.newAPI()
+	if err != nil {
+		return err
+	}
+	u.api = api
 
-	// 创建用户
-	for i := 0; i < u.userVar.num; i++ {
-		uid := fmt.Sprintf("%s%d", u.userVar.prefix, i)
-		err := u.api.UpdateToken(uid, "test", wkproto.APP, 0)
+	credentials := &credentialSet{}
+	if u.tokenVar.credentials != "" && u.bulkVar.resume != "" {
+		err = credentials.load(u.tokenVar.credentials, u.tokenVar.format())
 		if err != nil {
-			return err
+			return fmt.Errorf("read credentials %s to resume: %w", u.tokenVar.credentials, err)
 		}
-		progress.Incr()
 	}
-	state = "Finished  "
-
-	return nil
+	tasks := make([]*bulkTask, 0, len(uids)*len(u.tokenVar.flags))
+	for _, uid := range uids {
+		for _, flag := range u.tokenVar.flags {
+			token, err := u.tokenVar.newToken(uid)
+			if err != nil {
+				return err
+			}
+			credential := &userCredential{
+				UID:         uid,
+				Token:       token,
+				DeviceFlag:  flag,
+				DeviceLevel: u.tokenVar.level,
+				seq:         len(tasks),
+			}
+			name := uid
+			if len(u.tokenVar.flags) > 1 {
+				name = fmt.Sprintf("%s/%d", uid, flag)
+			}
+			tasks = append(tasks, &bulkTask{
+				name: name,
+				uids: []string{uid},
+				run: func(ctx context.Context) error {
+					err := u.api.UpdateToken(ctx, &wkapi.UpdateTokenReq{
+						UID:         credential.UID,
+						Token:       credential.Token,
+						DeviceFlag:  credential.DeviceFlag,
+						DeviceLevel: credential.DeviceLevel,
+					})
+					if err == nil {
+						credentials.add(credential)
+					}
+					return err
+				},
+			})
+		}
+	}
+	err = u.ctx.runBulk(cmd, u.bulkVar, tasks)
+	if u.tokenVar.credentials == "" || u.bulkVar.dryRun {
+		return err
+	}
+	// 部分失败时也写入已经设置成功的用户
+	writeErr := credentials.write(u.tokenVar.credentials, u.tokenVar.format())
+	if writeErr != nil {
+		return fmt.Errorf("write credentials %s: %w", u.tokenVar.credentials, writeErr)
+	}
+	log.Printf("Wrote %d credentials to %s", len(credentials.items), u.tokenVar.credentials)
+	return err
 }
@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/spf13/cobra"
+)
+
+// importVar 导入用户的参数
+type importVar struct {
+	format      string // csv或jsonl，默认根据扩展名
+	skipInvalid bool   // 跳过校验失败的行
+}
+
+// importRow 导入文件中的一行
+type importRow struct {
+	line       int // 行号，JSON数组时为元素序号
+	credential *userCredential
+	err        error
+}
+
+// importFields 导入文件中的字段，device_flag和device_level可以是名称或者数字
+type importFields struct {
+	UID         string          `json:"uid"`
+	Token       string          `json:"token"`
+	DeviceFlag  json.RawMessage `json:"device_flag"`
+	DeviceLevel json.RawMessage `json:"device_level"`
+}
+
+func (u *userCMD) initImportVar(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&u.importVar.format, "format", "", "File format: csv or jsonl (default from the file extension; .json may also hold one JSON array)")
+	cmd.Flags().BoolVar(&u.importVar.skipInvalid, "skip-invalid", false, "Import the valid rows even if some rows fail validation")
+	u.bulkVar.initFlags(cmd)
+}
+
+func (u *userCMD) runImport(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Help()
+		return nil
+	}
+	rows, err := u.readImport(args[0])
+	if err != nil {
+		return err
+	}
+	credentials, invalid := validateImport(rows)
+	for _, row := range invalid {
+		fmt.Fprintf(os.Stderr, "%s:%d: %v\n", args[0], row.line, row.err)
+	}
+	if len(invalid) > 0 && !u.importVar.skipInvalid {
+		return fmt.Errorf("%d of %d rows in %s are invalid, fix them or pass --skip-invalid", len(invalid), len(rows), args[0])
+	}
+	if len(credentials) == 0 {
+		return fmt.Errorf("no users to import in %s", args[0])
+	}
+
+	api, err := u.ctx.newAPI()
+	if err != nil {
+		return err
+	}
+	u.api = api
+	log.Printf("Starting import user, rows: %d, skipped invalid: %d", len(credentials), len(invalid))
+
+	tasks := make([]*bulkTask, 0, len(credentials))
+	for _, row := range credentials {
+		credential := row.credential
+		tasks = append(tasks, &bulkTask{
+			name: fmt.Sprintf("line %d: %s/%d", row.line, credential.UID, credential.DeviceFlag),
+			uids: []string{credential.UID},
+			run: func(ctx context.Context) error {
+				return u.api.UpdateToken(ctx, &wkapi.UpdateTokenReq{
+					UID:         credential.UID,
+					Token:       credential.Token,
+					DeviceFlag:  credential.DeviceFlag,
+					DeviceLevel: credential.DeviceLevel,
+				})
+			},
+		})
+	}
+	return u.ctx.runBulk(cmd, u.bulkVar, tasks)
+}
+
+// readImport 读取导入文件的所有行，解析失败的行记录在row.err中
+func (u *userCMD) readImport(path string) ([]*importRow, error) {
+	format := strings.ToLower(u.importVar.format)
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".jsonl", ".ndjson", ".json":
+			format = "jsonl"
+		default:
+			format = "csv"
+		}
+	}
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	switch format {
+	case "csv":
+		return readImportCSV(r)
+	case "jsonl":
+		return readImportJSON(r)
+	}
+	return nil, fmt.Errorf("invalid --format %q, must be csv or jsonl", u.importVar.format)
+}
+
+// readImportCSV 第一行为表头，需要uid和token列，device_flag和device_level列可选
+func readImportCSV(r io.Reader) ([]*importRow, error) {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"uid", "token"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q in the csv header, expected uid,token[,device_flag][,device_level]", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []*importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			rows = append(rows, &importRow{line: parseErr.StartLine, err: parseErr.Err})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, newImportRow(line, field(record, "uid"), field(record, "token"), field(record, "device_flag"), field(record, "device_level")))
+	}
+	return rows, nil
+}
+
+// readImportJSON 每行一个JSON对象，或者整个文件为一个JSON数组（user create --credentials 写入的格式）
+func readImportJSON(r io.Reader) ([]*importRow, error) {
+	reader := bufio.NewReader(r)
+	data, err := reader.Peek(1)
+	for err == nil && len(bytes.TrimSpace(data)) == 0 {
+		reader.ReadByte()
+		data, err = reader.Peek(1)
+	}
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*importRow
+	if data[0] == '[' {
+		var items []json.RawMessage
+		err = json.NewDecoder(reader).Decode(&items)
+		if err != nil {
+			return nil, fmt.Errorf("invalid json array: %w", err)
+		}
+		for i, item := range items {
+			rows = append(rows, decodeImportRow(i+1, item))
+		}
+		return rows, nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+		rows = append(rows, decodeImportRow(line, text))
+	}
+	return rows, scanner.Err()
+}
+
+func decodeImportRow(line int, data []byte) *importRow {
+	fields := &importFields{}
+	err := json.Unmarshal(data, fields)
+	if err != nil {
+		return &importRow{line: line, err: err}
+	}
+	return newImportRow(line, fields.UID, fields.Token, jsonScalar(fields.DeviceFlag), jsonScalar(fields.DeviceLevel))
+}
+
+// jsonScalar JSON字符串或者数字的文本
+func jsonScalar(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// newImportRow 校验一行的内容，设备标识默认为app，设备等级默认为从设备
+func newImportRow(line int, uid, token, deviceFlag, deviceLevel string) *importRow {
+	row := &importRow{line: line}
+	credential := &userCredential{UID: uid, Token: token, DeviceFlag: wkproto.APP, DeviceLevel: wkproto.DeviceLevelSlave}
+	switch {
+	case uid == "":
+		row.err = errors.New("uid is empty")
+	case strings.ContainsAny(uid, " \t\r\n"):
+		row.err = fmt.Errorf("uid %q contains whitespace", uid)
+	case token == "":
+		row.err = fmt.Errorf("token of %q is empty", uid)
+	}
+	if row.err != nil {
+		return row
+	}
+	var err error
+	if deviceFlag != "" {
+		credential.DeviceFlag, err = parseDeviceFlag(deviceFlag)
+	}
+	if err == nil && deviceLevel != "" {
+		credential.DeviceLevel, err = parseDeviceLevel(deviceLevel)
+	}
+	if err != nil {
+		row.err = err
+		return row
+	}
+	row.credential = credential
+	return row
+}
+
+// validateImport 分出有效和无效的行，同一用户同一设备重复的行只导入一次，token不同时都视为无效
+func validateImport(rows []*importRow) ([]*importRow, []*importRow) {
+	type key struct {
+		uid  string
+		flag wkproto.DeviceFlag
+	}
+	first := make(map[key]*importRow, len(rows))
+	conflicts := make(map[key]bool)
+	for _, row := range rows {
+		if row.err != nil {
+			continue
+		}
+		k := key{row.credential.UID, row.credential.DeviceFlag}
+		prev := first[k]
+		if prev == nil {
+			first[k] = row
+			continue
+		}
+		if prev.credential.Token != row.credential.Token || prev.credential.DeviceLevel != row.credential.DeviceLevel {
+			conflicts[k] = true
+			row.err = fmt.Errorf("%s/%d conflicts with line %d", row.credential.UID, row.credential.DeviceFlag, prev.line)
+		}
+	}
+
+	var valid, invalid []*importRow
+	for _, row := range rows {
+		if row.err != nil {
+			invalid = append(invalid, row)
+			continue
+		}
+		k := key{row.credential.UID, row.credential.DeviceFlag}
+		if conflicts[k] {
+			if first[k] == row {
+				row.err = fmt.Errorf("%s/%d has conflicting rows", row.credential.UID, row.credential.DeviceFlag)
+				invalid = append(invalid, row)
+			}
+			continue
+		}
+		if first[k] == row {
+			valid = append(valid, row)
+		}
+	}
+	return valid, invalid
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateImport(t *testing.T) {
+	// 每行依次为 uid, token, device_flag, device_level
+	tests := []struct {
+		name        string
+		rows        [][4]string
+		wantValid   []int // 有效行的行号
+		wantInvalid []int // 无效行的行号
+		wantErr     map[int]string
+	}{
+		{
+			name:      "all valid",
+			rows:      [][4]string{{"u1", "t1", "", ""}, {"u2", "t2", "web", "master"}},
+			wantValid: []int{1, 2},
+		},
+		{
+			name:      "identical duplicates imported once",
+			rows:      [][4]string{{"u1", "t1", "app", ""}, {"u1", "t1", "0", "slave"}, {"u1", "t1", "", "0"}},
+			wantValid: []int{1},
+		},
+		{
+			name:      "same uid on other devices",
+			rows:      [][4]string{{"u1", "t1", "app", ""}, {"u1", "t2", "web", ""}, {"u1", "t3", "pc", ""}},
+			wantValid: []int{1, 2, 3},
+		},
+		{
+			name:        "different token conflicts",
+			rows:        [][4]string{{"u1", "t1", "", ""}, {"u2", "t2", "", ""}, {"u1", "t9", "", ""}},
+			wantValid:   []int{2},
+			wantInvalid: []int{1, 3},
+			wantErr:     map[int]string{1: "has conflicting rows", 3: "conflicts with line 1"},
+		},
+		{
+			name:        "different device level conflicts",
+			rows:        [][4]string{{"u1", "t1", "", "slave"}, {"u1", "t1", "", "master"}},
+			wantInvalid: []int{1, 2},
+			wantErr:     map[int]string{1: "has conflicting rows", 2: "conflicts with line 1"},
+		},
+		{
+			name:        "duplicate of a conflicting row not reported twice",
+			rows:        [][4]string{{"u1", "t1", "", ""}, {"u1", "t2", "", ""}, {"u1", "t1", "", ""}},
+			wantInvalid: []int{1, 2},
+		},
+		{
+			name:        "invalid rows",
+			rows:        [][4]string{{"", "t1", "", ""}, {"u 2", "t2", "", ""}, {"u3", "", "", ""}, {"u4", "t4", "tv", ""}, {"u5", "t5", "", "2"}, {"u6", "t6", "", ""}},
+			wantValid:   []int{6},
+			wantInvalid: []int{1, 2, 3, 4, 5},
+			wantErr:     map[int]string{1: "uid is empty", 2: "contains whitespace", 3: "token of \"u3\" is empty", 4: "device flag", 5: "device level"},
+		},
+		{
+			name:        "invalid row does not conflict",
+			rows:        [][4]string{{"u1", "", "", ""}, {"u1", "t1", "", ""}},
+			wantValid:   []int{2},
+			wantInvalid: []int{1},
+		},
+	}
+	lines := func(rows []*importRow) []int {
+		var result []int
+		for _, row := range rows {
+			result = append(result, row.line)
+		}
+		return result
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := make([]*importRow, 0, len(tt.rows))
+			for i, r := range tt.rows {
+				rows = append(rows, newImportRow(i+1, r[0], r[1], r[2], r[3]))
+			}
+			valid, invalid := validateImport(rows)
+			if got := lines(valid); !reflect.DeepEqual(got, tt.wantValid) {
+				t.Errorf("valid lines = %v, want %v", got, tt.wantValid)
+			}
+			if got := lines(invalid); !reflect.DeepEqual(got, tt.wantInvalid) {
+				t.Errorf("invalid lines = %v, want %v", got, tt.wantInvalid)
+			}
+			for _, row := range invalid {
+				if want, ok := tt.wantErr[row.line]; ok && (row.err == nil || !strings.Contains(row.err.Error(), want)) {
+					t.Errorf("line %d error = %v, want %q", row.line, row.err, want)
+				}
+			}
+		})
+	}
+}
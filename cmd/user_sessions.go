@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"text/tabwriter"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
+	"github.com/spf13/cobra"
+)
+
+// sessionsLimit 每次查询的连接数，超过时分页查询
+const sessionsLimit = 1000
+
+// userSession 用户的一个连接
+type userSession struct {
+	Node string `json:"node"` // 连接所在节点的http api地址
+	*wkapi.ConnInfo
+}
+
+// sessionNode 上下文中的一个节点
+type sessionNode struct {
+	endpoint string
+	tcpAddr  string
+	api      wkapi.Interface
+}
+
+func (u *userCMD) runSessions(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Help()
+		return nil
+	}
+	uid := args[0]
+	ctx := cmd.Context()
+	api, err := u.ctx.newAPI()
+	if err != nil {
+		return err
+	}
+	var routeAddr string
+	route, err := api.Route(ctx, []string{uid})
+	if err != nil {
+		log.Printf("Route %s failed, querying all servers: %v", uid, err)
+	} else {
+		routeAddr = route[uid]
+	}
+
+	nodes, err := u.sessionNodes(ctx, routeAddr)
+	if err != nil {
+		return err
+	}
+	sessions := make([]*userSession, 0)
+	for _, node := range nodes {
+		nodeSessions, err := node.sessions(ctx, uid)
+		if err != nil {
+			return fmt.Errorf("query connections on %s: %w", node.endpoint, err)
+		}
+		sessions = append(sessions, nodeSessions...)
+	}
+
+	return u.ctx.out.render(sessions, func(out io.Writer) error {
+		if len(sessions) == 0 {
+			fmt.Fprintf(out, "No connections of %s\n", uid)
+			return nil
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NODE\tADDR\tDEVICE\tDEVICE ID\tVERSION\tIDLE\tPENDING\tIN MSGS\tOUT MSGS\tIN BYTES\tOUT BYTES")
+		for _, s := range sessions {
+			fmt.Fprintf(w, "%s\t%s:%d\t%s\t%s\t%d\t%s\t%s\t%d\t%d\t%s\t%s\n", s.Node, s.IP, s.Port, s.Device, s.DeviceID, s.Version, s.Idle,
+				Psize(false, int64(s.PendingBytes)), s.InMsgs, s.OutMsgs, Psize(false, s.InBytes), Psize(false, s.OutBytes))
+		}
+		return w.Flush()
+	})
+}
+
+// sessions 分页查询节点上用户的所有连接
+func (n *sessionNode) sessions(ctx context.Context, uid string) ([]*userSession, error) {
+	sessions := make([]*userSession, 0)
+	seen := make(map[int64]bool)
+	for offset := 0; ; {
+		connz, err := n.api.Connz(ctx, &wkapi.ConnzReq{UID: uid, Offset: offset, Limit: sessionsLimit})
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, conn := range connz.Connections {
+			if seen[conn.ID] {
+				continue
+			}
+			seen[conn.ID] = true
+			added++
+			// 不支持按uid查询的服务端会返回所有连接
+			if conn.UID == uid {
+				sessions = append(sessions, &userSession{Node: n.endpoint, ConnInfo: conn})
+			}
+		}
+		offset += len(connz.Connections)
+		// 已经查询到总数，或者本页没有新的连接（最后一页或者服务端不支持分页）
+		// 服务端可能限制每页的数量，不能按本页的数量判断是否是最后一页
+		if added == 0 || (connz.Total > 0 && offset >= connz.Total) {
+			return sessions, nil
+		}
+	}
+}
+
+// sessionNodes 用户所在的节点（tcp地址与路由结果相同的节点），找不到时提示并返回所有可访问的节点
+func (u *userCMD) sessionNodes(ctx context.Context, routeAddr string) ([]*sessionNode, error) {
+	endpoints := u.ctx.opts.Endpoints()
+	nodes := make([]*sessionNode, 0, len(endpoints))
+	var lastErr error
+	for _, endpoint := range endpoints {
+		api, err := u.ctx.newAPI(wkapi.WithEndpoints(endpoint))
+		if err != nil {
+			return nil, err
+		}
+		node := &sessionNode{endpoint: endpoint, api: api}
+		varz, err := api.Varz(ctx)
+		if err != nil {
+			// 只有一个节点时仍然查询它的连接
+			if len(endpoints) > 1 {
+				log.Printf("Skip %s: %v", endpoint, err)
+				lastErr = err
+				continue
+			}
+			log.Printf("Varz %s failed: %v", endpoint, err)
+		} else {
+			node.tcpAddr = varz.TCPAddr
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, fmt.Errorf("no server address configured")
+	}
+	if routeAddr != "" {
+		for _, node := range nodes {
+			if node.tcpAddr == routeAddr {
+				return []*sessionNode{node}, nil
+			}
+		}
+		// 路由到的节点不在上下文中时，查询到的连接可能不完整
+		log.Printf("Warning: user is routed to %s, which matches no configured server; connections on it are not listed", routeAddr)
+	}
+	return nodes, nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
+)
+
+// connzAPI 只实现Connz的测试API
+type connzAPI struct {
+	wkapi.Interface
+	conns       []*wkapi.ConnInfo
+	ignorePages bool // 模拟不支持分页的服务端
+	maxLimit    int  // 模拟限制每页数量的服务端
+	noTotal     bool // 模拟不返回总数的服务端
+	calls       int
+}
+
+func (a *connzAPI) Connz(ctx context.Context, req *wkapi.ConnzReq) (*wkapi.Connz, error) {
+	a.calls++
+	offset := req.Offset
+	if a.ignorePages {
+		offset = 0
+	}
+	limit := req.Limit
+	if a.maxLimit > 0 && limit > a.maxLimit {
+		limit = a.maxLimit
+	}
+	if offset > len(a.conns) {
+		offset = len(a.conns)
+	}
+	end := offset + limit
+	if end > len(a.conns) {
+		end = len(a.conns)
+	}
+	total := len(a.conns)
+	if a.noTotal {
+		total = 0
+	}
+	return &wkapi.Connz{Connections: a.conns[offset:end], Total: total, Offset: offset, Limit: limit}, nil
+}
+
+func TestSessionNodeSessions(t *testing.T) {
+	newConns := func(n int) []*wkapi.ConnInfo {
+		conns := make([]*wkapi.ConnInfo, 0, n)
+		for i := 0; i < n; i++ {
+			uid := "u1"
+			if i%2 == 1 {
+				uid = "u2"
+			}
+			conns = append(conns, &wkapi.ConnInfo{ID: int64(i + 1), UID: uid})
+		}
+		return conns
+	}
+	tests := []struct {
+		name      string
+		api       *connzAPI
+		want      int
+		wantCalls int
+	}{
+		{"one page", &connzAPI{conns: newConns(10)}, 5, 1},
+		{"several pages", &connzAPI{conns: newConns(2*sessionsLimit + 10)}, sessionsLimit + 5, 3},
+		{"exactly full pages", &connzAPI{conns: newConns(2 * sessionsLimit)}, sessionsLimit, 2},
+		{"no paging support", &connzAPI{conns: newConns(2 * sessionsLimit), ignorePages: true}, sessionsLimit / 2, 2},
+		{"server clamps limit", &connzAPI{conns: newConns(250), maxLimit: 100}, 125, 3},
+		{"server clamps limit without total", &connzAPI{conns: newConns(250), maxLimit: 100, noTotal: true}, 125, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &sessionNode{endpoint: "http://a:5001", api: tt.api}
+			sessions, err := node.sessions(context.Background(), "u1")
+			if err != nil {
+				t.Fatalf("sessions() error = %v", err)
+			}
+			if len(sessions) != tt.want {
+				t.Errorf("sessions() = %d sessions, want %d", len(sessions), tt.want)
+			}
+			if tt.api.calls != tt.wantCalls {
+				t.Errorf("Connz called %d times, want %d", tt.api.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
+	"github.com/spf13/cobra"
+)
+
+// onlineStatusBatch 每次查询在线状态的最多用户数
+const onlineStatusBatch = 1000
+
+// userStatus 用户的在线状态
+type userStatus struct {
+	UID     string   `json:"uid"`
+	Online  bool     `json:"online"`
+	Devices []string `json:"devices"` // 在线的设备
+}
+
+// userKick 强制下线的结果
+type userKick struct {
+	UID    string `json:"uid"`
+	Device string `json:"device"` // 下线的设备，all表示所有设备
+}
+
+func (u *userCMD) runStatus(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		cmd.Help()
+		return nil
+	}
+	uids, err := expandIDs(args)
+	if err != nil {
+		return err
+	}
+	api, err := u.ctx.newAPI()
+	if err != nil {
+		return err
+	}
+
+	statuses := make([]*userStatus, 0, len(uids))
+	byUID := make(map[string]*userStatus, len(uids))
+	for _, uid := range uids {
+		status := &userStatus{UID: uid, Devices: make([]string, 0)}
+		statuses = append(statuses, status)
+		byUID[uid] = status
+	}
+	for start := 0; start < len(uids); start += onlineStatusBatch {
+		end := start + onlineStatusBatch
+		if end > len(uids) {
+			end = len(uids)
+		}
+		online, err := api.OnlineStatus(cmd.Context(), uids[start:end])
+		if err != nil {
+			return err
+		}
+		for _, o := range online {
+			status := byUID[o.UID]
+			if status == nil || o.Online != 1 {
+				continue
+			}
+			status.Online = true
+			status.Devices = append(status.Devices, deviceName(o.DeviceFlag))
+		}
+	}
+
+	return u.ctx.out.render(statuses, func(out io.Writer) error {
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "UID\tSTATUS\tDEVICES")
+		for _, s := range statuses {
+			state := u.ctx.out.colored(colorRed, "offline")
+			if s.Online {
+				state = u.ctx.out.colored(colorGreen, "online")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.UID, state, strings.Join(s.Devices, ","))
+		}
+		return w.Flush()
+	})
+}
+
+func (u *userCMD) runKick(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Help()
+		return nil
+	}
+	req := &wkapi.DeviceQuitReq{UID: args[0], DeviceFlag: wkapi.DeviceQuitAll}
+	device := strings.ToLower(strings.TrimSpace(u.userVar.kickDevice))
+	if device != "all" {
+		flag, err := parseDeviceFlag(device)
+		if err != nil {
+			return fmt.Errorf("invalid --device-flag: %w", err)
+		}
+		req.DeviceFlag = int(flag)
+		device = deviceName(flag)
+	}
+	api, err := u.ctx.newAPI()
+	if err != nil {
+		return err
+	}
+	if u.userVar.kickDryRun {
+		tasks := []*bulkTask{{
+			name: req.UID,
+			uids: []string{req.UID},
+			run: func(ctx context.Context) error {
+				return api.DeviceQuit(ctx, req)
+			},
+		}}
+		return u.ctx.dryRunBulk(cmd, tasks, newBulkImpact(tasks))
+	}
+	err = api.DeviceQuit(cmd.Context(), req)
+	if err != nil {
+		return err
+	}
+	result := &userKick{UID: req.UID, Device: device}
+	return u.ctx.out.render(result, func(out io.Writer) error {
+		if device == "all" {
+			_, err := fmt.Fprintf(out, "All devices of %s were forced offline\n", req.UID)
+			return err
+		}
+		_, err := fmt.Fprintf(out, "Device %s of %s was forced offline\n", device, req.UID)
+		return err
+	})
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/network"
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkapi"
+	"github.com/sendgrid/rest"
+	"github.com/spf13/cobra"
+)
+
+type systemVar struct {
+	dryRun bool // 只输出请求，不发送
+	confirmVar
+}
+
+func (s *systemVar) initFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&s.dryRun, "dry-run", false, "Print the request without sending it")
+	s.confirmVar.initFlags(cmd)
+}
+
+// systemResult wk user system add/remove 的结果
+type systemResult struct {
+	Command string   `json:"command"`
+	Uids    []string `json:"uids"`
+}
+
+// systemCMD 系统账号命令，系统账号可以在封禁的频道中发送消息
+func (u *userCMD) systemCMD() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "system",
+		Short: "Manage system uids, which may send to banned channels",
+	}
+	add := &cobra.Command{
+		Use:     "add <uids...>",
+		Short:   "Add system uids",
+		Example: `  wk user system add admin1 bot[1-3]`,
+		RunE:    u.runSystemAdd,
+	}
+	u.systemVar.initFlags(add)
+	remove := &cobra.Command{
+		Use:     "remove <uids...>",
+		Short:   "Remove system uids",
+		Example: `  wk user system remove bot3`,
+		RunE:    u.runSystemRemove,
+	}
+	u.systemVar.initFlags(remove)
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List system uids",
+		RunE:  u.runSystemList,
+	}
+	cmd.AddCommand(mutating(add), mutating(remove), list)
+	return cmd
+}
+
+func (u *userCMD) runSystemAdd(cmd *cobra.Command, args []string) error {
+	return u.runSystemChange(cmd, args, func(ctx context.Context, uids []string) error {
+		return u.api.SystemUIDsAdd(ctx, uids)
+	})
+}
+
+func (u *userCMD) runSystemRemove(cmd *cobra.Command, args []string) error {
+	return u.runSystemChange(cmd, args, func(ctx context.Context, uids []string) error {
+		return u.api.SystemUIDsRemove(ctx, uids)
+	})
+}
+
+// runSystemChange 一次请求添加或移除所有用户
+func (u *userCMD) runSystemChange(cmd *cobra.Command, args []string, change func(ctx context.Context, uids []string) error) error {
+	if len(args) == 0 {
+		cmd.Help()
+		return nil
+	}
+	err := u.systemVar.check()
+	if err != nil {
+		return err
+	}
+	uids, err := expandIDs(args)
+	if err != nil {
+		return err
+	}
+	api, err := u.ctx.newAPI()
+	if err != nil {
+		return err
+	}
+	u.api = api
+	command := commandKey(cmd)
+
+	if u.systemVar.dryRun {
+		var planned *plannedRequest
+		ctx := network.WithDryRun(cmd.Context(), func(request rest.Request) {
+			planned = newPlannedRequest(request)
+		})
+		err = change(ctx, uids)
+		if err != nil {
+			return err
+		}
+		return u.ctx.out.render(planned, func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, planned)
+			return err
+		})
+	}
+	err = u.ctx.confirmImpact(command, &u.systemVar.confirmVar, &bulkImpact{Uids: len(uids)})
+	if err != nil {
+		return err
+	}
+	err = change(cmd.Context(), uids)
+	if err != nil {
+		return err
+	}
+	result := &systemResult{Command: command, Uids: uids}
+	return u.ctx.out.render(result, func(w io.Writer) error {
+		if cmd.Name() == "remove" {
+			fmt.Fprintf(w, "Removed %d system uids\n", len(uids))
+		} else {
+			fmt.Fprintf(w, "Added %d system uids\n", len(uids))
+		}
+		return nil
+	})
+}
+
+func (u *userCMD) runSystemList(cmd *cobra.Command, args []string) error {
+	api, err := u.ctx.newAPI()
+	if err != nil {
+		return err
+	}
+	uids, err := api.SystemUIDs(cmd.Context())
+	if errors.Is(err, wkapi.ErrNotFound) {
+		return fmt.Errorf("system uids can still be added and removed, but not listed: %w", err)
+	}
+	if err != nil {
+		return err
+	}
+	return u.ctx.out.render(uids, func(out io.Writer) error {
+		if len(uids) == 0 {
+			fmt.Fprintln(out, "No system uids")
+			return nil
+		}
+		for _, uid := range uids {
+			fmt.Fprintln(out, uid)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/spf13/cobra"
+)
+
+// token的生成方式
+const (
+	tokenFixed  = "fixed"  // 所有用户使用同一个token
+	tokenRandom = "random" // 每个用户每个设备随机生成
+	tokenHMAC   = "hmac"   // hex(HMAC-SHA256(secret, uid))，其他工具可以用同样的secret计算出token
+)
+
+// deviceFlags 设备标识的名称
+var deviceFlags = map[string]wkproto.DeviceFlag{
+	"app": wkproto.APP,
+	"web": wkproto.WEB,
+	"pc":  wkproto.PC,
+}
+
+// deviceName 设备标识的名称，未知的设备为数字
+func deviceName(flag wkproto.DeviceFlag) string {
+	for name, f := range deviceFlags {
+		if f == flag {
+			return name
+		}
+	}
+	return strconv.Itoa(int(flag))
+}
+
+// deviceLevels 设备等级的名称
+var deviceLevels = map[string]wkproto.DeviceLevel{
+	"slave":  wkproto.DeviceLevelSlave,
+	"master": wkproto.DeviceLevelMaster,
+}
+
+// parseDeviceFlag 解析设备标识，名称（app、web、pc）或者数字（0、1、2）
+func parseDeviceFlag(value string) (wkproto.DeviceFlag, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if flag, ok := deviceFlags[value]; ok {
+		return flag, nil
+	}
+	for _, flag := range deviceFlags {
+		if value == strconv.Itoa(int(flag)) {
+			return flag, nil
+		}
+	}
+	return 0, fmt.Errorf("device flag %q must be app, web, pc or 0, 1, 2", value)
+}
+
+// parseDeviceLevel 解析设备等级，名称（slave、master）或者数字（0、1）
+func parseDeviceLevel(value string) (wkproto.DeviceLevel, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if level, ok := deviceLevels[value]; ok {
+		return level, nil
+	}
+	for _, level := range deviceLevels {
+		if value == strconv.Itoa(int(level)) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("device level %q must be slave, master or 0, 1", value)
+}
+
+// tokenVar 设置用户token的参数
+type tokenVar struct {
+	deviceFlags     []string // 设备标识，每个设备设置一次token
+	deviceLevel     string   // 设备等级
+	mode            string   // token的生成方式
+	token           string   // fixed使用的token
+	credentials     string   // 写入uid/token/设备的文件
+	credentialsType string   // 文件格式 csv或json，默认根据扩展名
+
+	flags  []wkproto.DeviceFlag
+	level  wkproto.DeviceLevel
+	secret []byte
+}
+
+func (t *tokenVar) initFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&t.deviceFlags, "device-flag", []string{"app"}, "Devices to set the token for: app, web, pc (comma separated for several)")
+	cmd.Flags().StringVar(&t.deviceLevel, "device-level", "slave", "Device level: master or slave")
+	cmd.Flags().StringVar(&t.mode, "token-mode", tokenFixed, "How tokens are made: fixed (--user-token), random, or hmac (hex HMAC-SHA256 of the uid, secret from env "+envTokenSecret+")")
+	cmd.Flags().StringVar(&t.token, "user-token", "test", "Token of every user with --token-mode fixed")
+	cmd.Flags().StringVar(&t.credentials, "credentials", "", "Write uid, token, device flag and level of every user that was set to this file")
+	cmd.Flags().StringVar(&t.credentialsType, "credentials-format", "", "Format of --credentials: csv or json (default from the file extension, csv otherwise)")
+}
+
+// check 检查参数，hmac需要读取secret
+func (t *tokenVar) check() error {
+	t.flags = t.flags[:0]
+	for _, name := range t.deviceFlags {
+		flag, err := parseDeviceFlag(name)
+		if err != nil {
+			return fmt.Errorf("invalid --device-flag: %w", err)
+		}
+		t.flags = append(t.flags, flag)
+	}
+	if len(t.flags) == 0 {
+		return fmt.Errorf("--device-flag must not be empty")
+	}
+	level, err := parseDeviceLevel(t.deviceLevel)
+	if err != nil {
+		return fmt.Errorf("invalid --device-level: %w", err)
+	}
+	t.level = level
+
+	switch t.mode {
+	case tokenFixed:
+		if t.token == "" {
+			return fmt.Errorf("--user-token must not be empty")
+		}
+	case tokenRandom:
+	case tokenHMAC:
+		secret, err := readPassphrase(envTokenSecret, "Secret to derive user tokens: ", false)
+		if err != nil {
+			return err
+		}
+		t.secret = []byte(secret)
+	default:
+		return fmt.Errorf("invalid --token-mode %q, must be fixed, random or hmac", t.mode)
+	}
+
+	if t.credentials != "" && t.format() == "" {
+		return fmt.Errorf("invalid --credentials-format %q, must be csv or json", t.credentialsType)
+	}
+	return nil
+}
+
+// newToken 生成用户的token
+func (t *tokenVar) newToken(uid string) (string, error) {
+	switch t.mode {
+	case tokenRandom:
+		buf := make([]byte, 16)
+		_, err := rand.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(buf), nil
+	case tokenHMAC:
+		mac := hmac.New(sha256.New, t.secret)
+		mac.Write([]byte(uid))
+		return hex.EncodeToString(mac.Sum(nil)), nil
+	}
+	return t.token, nil
+}
+
+func (t *tokenVar) format() string {
+	format := strings.ToLower(t.credentialsType)
+	if format == "" {
+		if strings.EqualFold(filepath.Ext(t.credentials), ".json") {
+			return "json"
+		}
+		return "csv"
+	}
+	if format != "csv" && format != "json" {
+		return ""
+	}
+	return format
+}
+
+// userCredential 设置成功的用户token，写入 --credentials 文件供其他压测工具使用
+type userCredential struct {
+	UID         string              `json:"uid"`
+	Token       string              `json:"token"`
+	DeviceFlag  wkproto.DeviceFlag  `json:"device_flag"`  // 0.app 1.web 2.pc
+	DeviceLevel wkproto.DeviceLevel `json:"device_level"` // 0.从设备 1.主设备
+
+	seq int // 在本次执行中的顺序
+}
+
+// credentialSet 并发收集设置成功的用户token
+type credentialSet struct {
+	mu    sync.Mutex
+	items []*userCredential
+}
+
+func (s *credentialSet) add(c *userCredential) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append(s.items, c)
+}
+
+// load 读取文件中已有的token，写入时同一用户同一设备以本次设置的为准
+// --resume 时只会重新设置失败的用户，之前设置成功的用户不能丢失
+func (s *credentialSet) load(path, format string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var rows []*importRow
+	if format == "json" {
+		rows, err = readImportJSON(f)
+	} else {
+		rows, err = readImportCSV(f)
+	}
+	if err != nil {
+		return err
+	}
+	for i, row := range rows {
+		if row.err != nil {
+			return fmt.Errorf("line %d: %w", row.line, row.err)
+		}
+		// 已有的排在本次设置的前面，保持原来的顺序
+		row.credential.seq = i - len(rows)
+		s.add(row.credential)
+	}
+	return nil
+}
+
+// write 按执行顺序写入文件，文件包含token所以只有当前用户可读
+func (s *credentialSet) write(path, format string) error {
+	sort.Slice(s.items, func(i, j int) bool { return s.items[i].seq < s.items[j].seq })
+	// 同一用户同一设备只保留最后设置的token，位置不变
+	type key struct {
+		uid  string
+		flag wkproto.DeviceFlag
+	}
+	positions := make(map[key]int, len(s.items))
+	items := make([]*userCredential, 0, len(s.items))
+	for _, c := range s.items {
+		k := key{c.UID, c.DeviceFlag}
+		if i, ok := positions[k]; ok {
+			items[i] = c
+			continue
+		}
+		positions[k] = len(items)
+		items = append(items, c)
+	}
+	s.items = items
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if format == "json" {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		items := s.items
+		if items == nil {
+			items = []*userCredential{}
+		}
+		err = enc.Encode(items)
+	} else {
+		w := csv.NewWriter(f)
+		w.Write([]string{"uid", "token", "device_flag", "device_level"})
+		for _, c := range s.items {
+			w.Write([]string{c.UID, c.Token, strconv.Itoa(int(c.DeviceFlag)), strconv.Itoa(int(c.DeviceLevel))})
+		}
+		w.Flush()
+		err = w.Error()
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+)
+
+// --resume 只重新设置失败的用户，第一次执行写入的token不能丢失
+func TestCredentialsKeptOnResume(t *testing.T) {
+	for _, format := range []string{"csv", "json"} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "users."+format)
+
+			// 第一次执行：u3失败
+			first := &credentialSet{}
+			first.add(&userCredential{UID: "u1", Token: "t1", seq: 0})
+			first.add(&userCredential{UID: "u2", Token: "t2", DeviceFlag: wkproto.PC, seq: 1})
+			if err := first.write(path, format); err != nil {
+				t.Fatal(err)
+			}
+
+			// --resume：只设置u3，同时重新设置了u1
+			resumed := &credentialSet{}
+			if err := resumed.load(path, format); err != nil {
+				t.Fatalf("load() error = %v", err)
+			}
+			resumed.add(&userCredential{UID: "u3", Token: "t3", seq: 0})
+			resumed.add(&userCredential{UID: "u1", Token: "t1-new", seq: 1})
+			if err := resumed.write(path, format); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			var rows []*importRow
+			if format == "json" {
+				rows, err = readImportJSON(f)
+			} else {
+				rows, err = readImportCSV(f)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []userCredential{
+				{UID: "u1", Token: "t1-new"},
+				{UID: "u2", Token: "t2", DeviceFlag: wkproto.PC},
+				{UID: "u3", Token: "t3"},
+			}
+			if len(rows) != len(want) {
+				t.Fatalf("got %d credentials, want %d", len(rows), len(want))
+			}
+			for i, row := range rows {
+				if row.err != nil {
+					t.Fatalf("line %d: %v", row.line, row.err)
+				}
+				got := row.credential
+				if got.UID != want[i].UID || got.Token != want[i].Token || got.DeviceFlag != want[i].DeviceFlag {
+					t.Errorf("credential %d = %s/%s/%d, want %s/%s/%d", i, got.UID, got.Token, got.DeviceFlag, want[i].UID, want[i].Token, want[i].DeviceFlag)
+				}
+			}
+		})
+	}
+}
+
+func TestCredentialsLoadMissingFile(t *testing.T) {
+	s := &credentialSet{}
+	if err := s.load(filepath.Join(t.TempDir(), "missing.csv"), "csv"); err != nil || len(s.items) != 0 {
+		t.Fatalf("load() of a missing file = %v, %d items", err, len(s.items))
+	}
+}
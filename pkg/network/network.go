@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -18,6 +19,12 @@ func Post(url string, body []byte, headers map[string]string) (resp *rest.Respon
 	return RequestBoy(url, body, headers, rest.Post)
 }
 
+// PostWithContext 发送POST请求，ctx取消时中止请求
+func PostWithContext(ctx context.Context, url string, body []byte, headers map[string]string) (resp *rest.Response, err error) {
+
+	return RequestBoyWithContext(ctx, url, body, headers, rest.Post)
+}
+
 func Put(url string, body []byte, headers map[string]string) (resp *rest.Response, err error) {
 
 	return RequestBoy(url, body, headers, rest.Put)
@@ -36,7 +43,7 @@ func RequestBoyForQueryParam(url string, queryParams map[string]string, headers
 		QueryParams: queryParams,
 		Headers:     headers,
 	}
-	response, err := rest.API(request)
+	response, err := DefaultTransport.Send(context.Background(), request)
 	if err != nil {
 		return nil, err
 	}
@@ -45,13 +52,19 @@ func RequestBoyForQueryParam(url string, queryParams map[string]string, headers
 }
 func RequestBoy(url string, body []byte, headers map[string]string, method rest.Method) (resp *rest.Response, err error) {
 
+	return RequestBoyWithContext(context.Background(), url, body, headers, method)
+}
+
+// RequestBoyWithContext 发送带body的请求，ctx取消时中止请求
+func RequestBoyWithContext(ctx context.Context, url string, body []byte, headers map[string]string, method rest.Method) (resp *rest.Response, err error) {
+
 	request := rest.Request{
 		Method:  method,
 		BaseURL: url,
 		Body:    body,
 		Headers: headers,
 	}
-	response, err := rest.API(request)
+	response, err := DefaultTransport.Send(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -60,13 +73,19 @@ func RequestBoy(url string, body []byte, headers map[string]string, method rest.
 
 func Get(url string, queryParams map[string]string, headers map[string]string) (resp *rest.Response, err error) {
 
+	return GetWithContext(context.Background(), url, queryParams, headers)
+}
+
+// GetWithContext 发送GET请求，ctx取消时中止请求
+func GetWithContext(ctx context.Context, url string, queryParams map[string]string, headers map[string]string) (resp *rest.Response, err error) {
+
 	request := rest.Request{
 		Method:      rest.Get,
 		BaseURL:     url,
 		Headers:     headers,
 		QueryParams: queryParams,
 	}
-	response, err := rest.API(request)
+	response, err := DefaultTransport.Send(ctx, request)
 	if err != nil {
 
 		return nil, err
@@ -83,7 +102,7 @@ func GetJson(url string, queryParams map[string]string, headers map[string]strin
 		Headers:     headers,
 		QueryParams: queryParams,
 	}
-	response, err := rest.API(request)
+	response, err := DefaultTransport.Send(context.Background(), request)
 	if err != nil {
 
 		return nil, err
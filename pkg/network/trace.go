@@ -0,0 +1,193 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sendgrid/rest"
+)
+
+// TraceLevel 请求跟踪的详细程度
+type TraceLevel int
+
+const (
+	TraceOff   TraceLevel = iota // 不输出
+	TraceBasic                   // 输出请求方法、地址、状态码和耗时
+	TraceFull                    // 同时输出请求头、请求内容和响应内容
+)
+
+// maxTraceBodyLen 跟踪输出中最多显示的响应内容长度
+const maxTraceBodyLen = 4096
+
+// redacted 打码后的请求头的值
+const redacted = "***"
+
+// Tracer 输出经过Transport的请求，可以在多个goroutine中使用
+type Tracer struct {
+	Out           io.Writer
+	Level         TraceLevel
+	Curl          bool     // 以可以直接执行的curl命令输出请求
+	RedactHeaders []string // 值需要打码的请求头（比如token），不区分大小写
+	RedactFields  []string // 值需要打码的JSON请求内容中的字段（比如用户token），区分大小写
+
+	mu sync.Mutex
+}
+
+func (t *Tracer) enabled() bool {
+	return t != nil && t.Out != nil && (t.Level > TraceOff || t.Curl)
+}
+
+func (t *Tracer) redact(name string) bool {
+	for _, h := range t.RedactHeaders {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// trace 输出一次请求（一次重试算一次请求）
+func (t *Tracer) trace(request rest.Request, attempt int, resp *rest.Response, err error, latency time.Duration) {
+	req, buildErr := rest.BuildRequestObject(request)
+	if buildErr != nil {
+		return
+	}
+	var b strings.Builder
+	if t.Curl {
+		b.WriteString(t.curl(req, t.redactBody(request.Body)))
+		b.WriteString("\n")
+	}
+	if t.Level > TraceOff {
+		retry := ""
+		if attempt > 0 {
+			retry = fmt.Sprintf(" (retry %d)", attempt)
+		}
+		if t.Level >= TraceFull {
+			fmt.Fprintf(&b, "> %s %s%s\n", req.Method, req.URL, retry)
+			for _, name := range sortedHeaderNames(req.Header) {
+				fmt.Fprintf(&b, "> %s: %s\n", name, t.headerValue(name, req.Header.Get(name)))
+			}
+			if len(request.Body) > 0 {
+				fmt.Fprintf(&b, ">\n> %s\n", t.redactBody(request.Body))
+			}
+			switch {
+			case err != nil:
+				fmt.Fprintf(&b, "< error: %v (%s)\n", err, latency.Round(time.Millisecond))
+			default:
+				fmt.Fprintf(&b, "< %d %s (%s)\n", resp.StatusCode, http.StatusText(resp.StatusCode), latency.Round(time.Millisecond))
+				if body := strings.TrimSpace(resp.Body); body != "" {
+					if len(body) > maxTraceBodyLen {
+						body = body[:maxTraceBodyLen] + "..."
+					}
+					fmt.Fprintf(&b, "< %s\n", body)
+				}
+			}
+		} else {
+			status := ""
+			if err != nil {
+				status = "error: " + err.Error()
+			} else {
+				status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+			}
+			fmt.Fprintf(&b, "%s %s%s -> %s (%s)\n", req.Method, req.URL, retry, status, latency.Round(time.Millisecond))
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	io.WriteString(t.Out, b.String())
+}
+
+func (t *Tracer) headerValue(name, value string) string {
+	if t.redact(name) {
+		return redacted
+	}
+	return value
+}
+
+// redactBody 打码JSON请求内容中的字段，没有需要打码的字段或者不是JSON时返回原内容
+func (t *Tracer) redactBody(body []byte) []byte {
+	if len(t.RedactFields) == 0 || len(body) == 0 {
+		return body
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if dec.Decode(&v) != nil || !t.redactValue(v) {
+		return body
+	}
+	redactedBody, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return redactedBody
+}
+
+// redactValue 递归打码对象中的字段，返回是否有字段被打码
+func (t *Tracer) redactValue(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if t.redactField(key) {
+				if value != nil && value != "" {
+					v[key] = redacted
+					changed = true
+				}
+				continue
+			}
+			if t.redactValue(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if t.redactValue(value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func (t *Tracer) redactField(name string) bool {
+	for _, f := range t.RedactFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// curl 请求对应的curl命令，需要打码的请求头会被替换为***
+func (t *Tracer) curl(req *http.Request, body []byte) string {
+	parts := []string{"curl", "-X", req.Method, shellQuote(req.URL.String())}
+	for _, name := range sortedHeaderNames(req.Header) {
+		parts = append(parts, "-H", shellQuote(name+": "+t.headerValue(name, req.Header.Get(name))))
+	}
+	if len(body) > 0 {
+		parts = append(parts, "--data-raw", shellQuote(string(body)))
+	}
+	return strings.Join(parts, " ")
+}
+
+func sortedHeaderNames(header http.Header) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// shellQuote 用单引号包裹，可以直接粘贴到shell中执行
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,47 @@
+package network
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	tracer := &Tracer{RedactFields: []string{"token"}}
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"token field", `{"uid":"u1","token":"secret"}`, `{"token":"***","uid":"u1"}`},
+		{"nested", `[{"uid":"u1","token":"a"},{"uid":"u2","token":"b"}]`, `[{"token":"***","uid":"u1"},{"token":"***","uid":"u2"}]`},
+		{"numbers kept", `{"token":"a","device_flag":1,"big":12345678901234567890}`, `{"big":12345678901234567890,"device_flag":1,"token":"***"}`},
+		{"no token unchanged", `{"uid":"u1", "b":1}`, `{"uid":"u1", "b":1}`},
+		{"empty token unchanged", `{"token":""}`, `{"token":""}`},
+		{"not json", `token=secret`, `token=secret`},
+		{"empty", ``, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(tracer.redactBody([]byte(tt.body)))
+			if got != tt.want {
+				t.Errorf("redactBody(%s) = %s, want %s", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurlRedacts(t *testing.T) {
+	tracer := &Tracer{RedactHeaders: []string{"token"}, RedactFields: []string{"token"}}
+	req, err := http.NewRequest(http.MethodPost, "http://127.0.0.1:5001/user/token", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("token", "manager")
+	got := tracer.curl(req, tracer.redactBody([]byte(`{"uid":"u1","token":"secret"}`)))
+	for _, secret := range []string{"manager", "secret"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("curl %q leaks %q", got, secret)
+		}
+	}
+}
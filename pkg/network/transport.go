@@ -0,0 +1,139 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sendgrid/rest"
+)
+
+// Transport http请求的传输配置：超时、重试和退避
+type Transport struct {
+	Client     *http.Client
+	Timeout    time.Duration // 单次请求的超时时间，0表示不超时
+	Retries    int           // 失败后的最大重试次数
+	MinBackoff time.Duration // 第一次重试前的等待时间
+	MaxBackoff time.Duration // 重试等待时间的上限
+	Tracer     *Tracer       // 输出每次请求，为nil时不输出
+}
+
+// NewTransport 创建默认配置的Transport
+func NewTransport() *Transport {
+	return &Transport{
+		Client:     &http.Client{},
+		Timeout:    10 * time.Second,
+		Retries:    3,
+		MinBackoff: 200 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+	}
+}
+
+// DefaultTransport Post、Get等函数使用的Transport
+var DefaultTransport = NewTransport()
+
+type retrySafeKey struct{}
+
+// WithRetrySafe 标记请求可以安全重试（重复执行不会产生额外的影响），GET请求默认可以重试
+func WithRetrySafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retrySafeKey{}, true)
+}
+
+// IsRetrySafe 请求是否可以安全重试：GET请求，或者通过WithRetrySafe标记的请求
+func IsRetrySafe(ctx context.Context, method rest.Method) bool {
+	if method == rest.Get {
+		return true
+	}
+	safe, _ := ctx.Value(retrySafeKey{}).(bool)
+	return safe
+}
+
+// IsDialError 是否是建立连接时的错误（比如连接被拒绝、域名无法解析），这时请求还没有发送
+func IsDialError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+type dryRunKey struct{}
+
+// WithDryRun 请求不会被发送，而是交给record，并返回一个内容为{}的200响应
+func WithDryRun(ctx context.Context, record func(request rest.Request)) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, record)
+}
+
+// IsDryRun 请求是否不会被发送（通过WithDryRun创建的ctx）
+func IsDryRun(ctx context.Context) bool {
+	_, ok := ctx.Value(dryRunKey{}).(func(rest.Request))
+	return ok
+}
+
+// Send 发送请求，可以安全重试的请求在网络错误或服务暂不可用时按退避时间重试
+func (t *Transport) Send(ctx context.Context, request rest.Request) (*rest.Response, error) {
+	if record, ok := ctx.Value(dryRunKey{}).(func(rest.Request)); ok {
+		record(request)
+		return &rest.Response{StatusCode: http.StatusOK, Body: "{}"}, nil
+	}
+	retries := 0
+	if IsRetrySafe(ctx, request.Method) {
+		retries = t.Retries
+	}
+	client := &rest.Client{HTTPClient: t.Client}
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		resp, err := t.send(ctx, client, request)
+		if t.Tracer.enabled() {
+			t.Tracer.trace(request, attempt, resp, err, time.Since(start))
+		}
+		if attempt >= retries || !shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(t.backoff(attempt)):
+		}
+	}
+}
+
+func (t *Transport) send(ctx context.Context, client *rest.Client, request rest.Request) (*rest.Response, error) {
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+	return client.SendWithContext(ctx, request)
+}
+
+// backoff 第attempt次重试前的等待时间，指数增长并加入随机抖动
+func (t *Transport) backoff(attempt int) time.Duration {
+	d := t.MinBackoff << uint(attempt)
+	if d <= 0 || d > t.MaxBackoff {
+		d = t.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func shouldRetry(ctx context.Context, resp *rest.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false // 已取消
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package wkapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkutil"
+)
+
+func (c *Client) Route(ctx context.Context, uids []string) (map[string]string, error) {
+
+	resp, err := c.post(ctx, "/route/batch", []byte(wkutil.ToJSON(uids)))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleError(http.MethodPost, "/route/batch", resp)
+	}
+
+	var userAddrs []userAddrResp
+	err = wkutil.ReadJSONByByte([]byte(resp.Body), &userAddrs)
+	if err != nil {
+		return nil, err
+	}
+
+	resultMap := make(map[string]string)
+	if len(userAddrs) > 0 {
+		for _, userAddr := range userAddrs {
+			if len(userAddr.UIDs) > 0 {
+				for _, uid := range userAddr.UIDs {
+					resultMap[uid] = userAddr.TCPAddr
+				}
+			}
+		}
+	}
+	return resultMap, nil
+
+}
+
+func (c *Client) UpdateToken(ctx context.Context, req *UpdateTokenReq) error {
+	return c.mutate(ctx, "/user/token", req)
+}
+
+// OnlineStatus 查询用户在线的设备
+func (c *Client) OnlineStatus(ctx context.Context, uids []string) ([]*OnlineStatus, error) {
+	resp, err := c.post(ctx, "/user/onlinestatus", []byte(wkutil.ToJSON(uids)))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleError(http.MethodPost, "/user/onlinestatus", resp)
+	}
+	statuses := make([]*OnlineStatus, 0)
+	err = wkutil.ReadJSONByByte([]byte(resp.Body), &statuses)
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// DeviceQuit 强制用户的设备下线
+func (c *Client) DeviceQuit(ctx context.Context, req *DeviceQuitReq) error {
+	return c.mutate(ctx, "/user/device_quit", req)
+}
+
+// SystemUIDsAdd 添加系统账号，系统账号可以在封禁的频道中发送消息
+func (c *Client) SystemUIDsAdd(ctx context.Context, uids []string) error {
+	return c.mutate(ctx, "/user/systemuids_add", &SystemUIDsReq{UIDs: uids})
+}
+
+// SystemUIDsRemove 移除系统账号
+func (c *Client) SystemUIDsRemove(ctx context.Context, uids []string) error {
+	return c.mutate(ctx, "/user/systemuids_remove", &SystemUIDsReq{UIDs: uids})
+}
+
+// SystemUIDs 查询所有系统账号，旧版本的服务端没有该接口
+func (c *Client) SystemUIDs(ctx context.Context) ([]string, error) {
+	resp, err := c.get(ctx, "/user/systemuids", nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleError(http.MethodGet, "/user/systemuids", resp)
+	}
+	uids := make([]string, 0)
+	err = wkutil.ReadJSONByByte([]byte(resp.Body), &uids)
+	if err != nil {
+		return nil, err
+	}
+	return uids, nil
+}
+
+func (c *Client) Varz(ctx context.Context) (*Varz, error) {
+	resp, err := c.get(ctx, "/varz", nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleError(http.MethodGet, "/varz", resp)
+	}
+	var varz *Varz
+	err = wkutil.ReadJSONByByte([]byte(resp.Body), &varz)
+	if err != nil {
+		return nil, err
+	}
+	return varz, nil
+}
+
+func (c *Client) Connz(ctx context.Context, req *ConnzReq) (*Connz, error) {
+	queryParams := map[string]string{}
+	if req != nil {
+		if req.Offset > 0 {
+			queryParams["offset"] = strconv.Itoa(req.Offset)
+		}
+		if req.Limit > 0 {
+			queryParams["limit"] = strconv.Itoa(req.Limit)
+		}
+		if req.Sort != "" {
+			queryParams["sort"] = req.Sort
+		}
+		if req.UID != "" {
+			queryParams["uid"] = req.UID
+		}
+	}
+	resp, err := c.get(ctx, "/connz", queryParams)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleError(http.MethodGet, "/connz", resp)
+	}
+	var connz *Connz
+	err = wkutil.ReadJSONByByte([]byte(resp.Body), &connz)
+	if err != nil {
+		return nil, err
+	}
+	return connz, nil
+}
+
+func (c *Client) CreateChannel(ctx context.Context, req *ChannelCreateReq) error {
+	return c.mutate(ctx, "/channel", req)
+}
+
+// SubscriberAdd 添加订阅者
+func (c *Client) SubscriberAdd(ctx context.Context, req *SubscriberAddReq) error {
+	return c.mutate(ctx, "/channel/subscriber_add", req)
+}
+
+// SubscriberRemove 移除订阅者
+func (c *Client) SubscriberRemove(ctx context.Context, req *SubscriberReq) error {
+	return c.mutate(ctx, "/channel/subscriber_remove", req)
+}
+
+// DenylistAdd 添加黑名单
+func (c *Client) DenylistAdd(ctx context.Context, req *ChannelUidsReq) error {
+	return c.mutate(ctx, "/channel/blacklist_add", req)
+}
+
+// DenylistRemove 移除黑名单
+func (c *Client) DenylistRemove(ctx context.Context, req *ChannelUidsReq) error {
+	return c.mutate(ctx, "/channel/blacklist_remove", req)
+}
+
+func (c *Client) AllowlistAdd(ctx context.Context, req *ChannelUidsReq) error {
+	return c.mutate(ctx, "/channel/whitelist_add", req)
+}
+
+func (c *Client) AllowlistRemove(ctx context.Context, req *ChannelUidsReq) error {
+	return c.mutate(ctx, "/channel/whitelist_remove", req)
+}
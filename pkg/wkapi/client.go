@@ -0,0 +1,290 @@
+// Package wkapi 悟空IM服务端http api的客户端
+package wkapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/network"
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkutil"
+	"github.com/sendgrid/rest"
+)
+
+// TokenHeader 携带token的请求头
+const TokenHeader = "token"
+
+// Interface 悟空IM http api，测试时可以替换为其它实现
+type Interface interface {
+	// Route 查询用户所在节点的tcp地址，返回 uid -> tcp地址
+	Route(ctx context.Context, uids []string) (map[string]string, error)
+	// UpdateToken 更新用户的token
+	UpdateToken(ctx context.Context, req *UpdateTokenReq) error
+	// OnlineStatus 查询用户在线的设备，不在线的用户不会返回
+	OnlineStatus(ctx context.Context, uids []string) ([]*OnlineStatus, error)
+	// DeviceQuit 强制用户的设备下线
+	DeviceQuit(ctx context.Context, req *DeviceQuitReq) error
+	// SystemUIDsAdd 添加系统账号
+	SystemUIDsAdd(ctx context.Context, uids []string) error
+	// SystemUIDsRemove 移除系统账号
+	SystemUIDsRemove(ctx context.Context, uids []string) error
+	// SystemUIDs 查询所有系统账号，服务端不支持时返回ErrNotFound
+	SystemUIDs(ctx context.Context) ([]string, error)
+	// Varz 服务端状态
+	Varz(ctx context.Context) (*Varz, error)
+	// Connz 服务端连接信息
+	Connz(ctx context.Context, req *ConnzReq) (*Connz, error)
+	// CreateChannel 创建频道
+	CreateChannel(ctx context.Context, req *ChannelCreateReq) error
+	// SubscriberAdd 添加订阅者
+	SubscriberAdd(ctx context.Context, req *SubscriberAddReq) error
+	// SubscriberRemove 移除订阅者
+	SubscriberRemove(ctx context.Context, req *SubscriberReq) error
+	// DenylistAdd 添加黑名单
+	DenylistAdd(ctx context.Context, req *ChannelUidsReq) error
+	// DenylistRemove 移除黑名单
+	DenylistRemove(ctx context.Context, req *ChannelUidsReq) error
+	// AllowlistAdd 添加白名单
+	AllowlistAdd(ctx context.Context, req *ChannelUidsReq) error
+	// AllowlistRemove 移除白名单
+	AllowlistRemove(ctx context.Context, req *ChannelUidsReq) error
+}
+
+var _ Interface = (*Client)(nil)
+
+// Client Interface的http实现，可以在多个goroutine中使用
+type Client struct {
+	addrs     []string
+	strategy  string
+	headers   map[string]string
+	transport *network.Transport
+	endpoints *endpointPool
+	readOnly  bool
+	onMutate  MutationHook
+}
+
+// Mutation 一次修改数据的请求及其结果
+type Mutation struct {
+	Method     string
+	Path       string
+	Body       []byte
+	StatusCode int           // 没有收到响应时为0
+	Err        error         // 请求失败或者服务端返回的错误
+	Latency    time.Duration // 请求耗时（包括重试）
+}
+
+// MutationHook 修改数据的请求（包括Do的非GET请求）完成后调用，可以用于审计
+// 可能在多个goroutine中同时调用，network.WithDryRun的请求不会调用
+type MutationHook func(ctx context.Context, m *Mutation)
+
+// Option 客户端配置
+type Option func(c *Client)
+
+// WithEndpoints 服务端http api地址，多个地址时按策略选择，地址无法连接时自动切换
+func WithEndpoints(addrs ...string) Option {
+	return func(c *Client) {
+		c.addrs = addrs
+	}
+}
+
+// WithStrategy 多个地址时的选择策略 StrategyFailover 或 StrategyRoundRobin
+func WithStrategy(strategy string) Option {
+	return func(c *Client) {
+		c.strategy = strategy
+	}
+}
+
+// WithHeaders 每次请求都需要携带的请求头
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Client) {
+		for k, v := range headers {
+			c.headers[k] = v
+		}
+	}
+}
+
+// WithToken 服务端要求的token
+func WithToken(token string) Option {
+	return func(c *Client) {
+		if token != "" {
+			c.headers[TokenHeader] = token
+		}
+	}
+}
+
+// WithTransport 请求的超时和重试配置，默认使用 network.DefaultTransport
+func WithTransport(transport *network.Transport) Option {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+// WithReadOnly 只允许查询，修改数据的请求返回 ErrReadOnly（network.WithDryRun的请求除外）
+func WithReadOnly() Option {
+	return func(c *Client) {
+		c.readOnly = true
+	}
+}
+
+// WithMutationHook 修改数据的请求完成后调用hook
+func WithMutationHook(hook MutationHook) Option {
+	return func(c *Client) {
+		c.onMutate = hook
+	}
+}
+
+// New 创建客户端
+func New(opts ...Option) *Client {
+	c := &Client{
+		headers: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.transport == nil {
+		c.transport = network.DefaultTransport
+	}
+	c.endpoints = newEndpointPool(c.addrs, c.strategy)
+	return c
+}
+
+func (c *Client) handleError(method, path string, resp *rest.Response) error {
+	return NewAPIError(method, path, resp.StatusCode, resp.Body)
+}
+
+// post 依次尝试可用的服务地址，地址无法连接时自动切换到下一个
+// 这里封装的服务端接口都是幂等的（重复调用结果相同），所以失败时可以安全重试
+func (c *Client) post(ctx context.Context, path string, body []byte) (*rest.Response, error) {
+	ctx = network.WithRetrySafe(ctx)
+	return c.do(ctx, rest.Post, func(baseURL string) (*rest.Response, error) {
+		return c.transport.Send(ctx, rest.Request{
+			Method:  rest.Post,
+			BaseURL: baseURL + path,
+			Body:    body,
+			Headers: c.headers,
+		})
+	})
+}
+
+// mutate 发送修改数据的请求，服务端没有返回200时返回APIError
+func (c *Client) mutate(ctx context.Context, path string, req interface{}) error {
+	if c.readOnly && !network.IsDryRun(ctx) {
+		return fmt.Errorf("%w, refusing POST %s", ErrReadOnly, path)
+	}
+	body := []byte(wkutil.ToJSON(req))
+	start := time.Now()
+	resp, err := c.post(ctx, path, body)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		err = c.handleError(http.MethodPost, path, resp)
+	}
+	c.mutated(ctx, http.MethodPost, path, body, resp, err, time.Since(start))
+	return err
+}
+
+func (c *Client) mutated(ctx context.Context, method, path string, body []byte, resp *rest.Response, err error, latency time.Duration) {
+	if c.onMutate == nil || network.IsDryRun(ctx) {
+		return
+	}
+	m := &Mutation{
+		Method:  method,
+		Path:    path,
+		Body:    body,
+		Err:     err,
+		Latency: latency,
+	}
+	if resp != nil {
+		m.StatusCode = resp.StatusCode
+	}
+	c.onMutate(ctx, m)
+}
+
+func (c *Client) get(ctx context.Context, path string, queryParams map[string]string) (*rest.Response, error) {
+	return c.do(ctx, rest.Get, func(baseURL string) (*rest.Response, error) {
+		return c.transport.Send(ctx, rest.Request{
+			Method:      rest.Get,
+			BaseURL:     baseURL + path,
+			QueryParams: queryParams,
+			Headers:     c.headers,
+		})
+	})
+}
+
+// do 依次尝试可用的服务地址
+// 可以安全重试的请求在节点无法访问或返回网关错误时切换到下一个节点；
+// 其它请求可能已经在节点上执行，只有在连接都没有建立时才切换，避免在其它节点上重复执行
+func (c *Client) do(ctx context.Context, method rest.Method, request func(baseURL string) (*rest.Response, error)) (*rest.Response, error) {
+	if len(c.endpoints.addrs) == 0 {
+		return nil, errors.New("no server address configured")
+	}
+	retrySafe := network.IsRetrySafe(ctx, method)
+	var (
+		lastResp *rest.Response
+		lastErr  error
+	)
+	for _, addr := range c.endpoints.candidates() {
+		resp, err := request(addr)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.endpoints.markDown(addr)
+			if !retrySafe && !network.IsDialError(err) {
+				return nil, err
+			}
+			lastResp, lastErr = nil, err
+			continue
+		}
+		// 网关错误说明这个节点当前不可用，换下一个节点
+		if gatewayError(resp.StatusCode) {
+			c.endpoints.markDown(addr)
+			if !retrySafe {
+				return resp, nil
+			}
+			lastResp, lastErr = resp, nil
+			continue
+		}
+		c.endpoints.markUp(addr)
+		return resp, nil
+	}
+	// 所有节点都失败了，返回最后一个响应或错误
+	return lastResp, lastErr
+}
+
+// gatewayError 节点前的网关或负载均衡返回的错误
+func gatewayError(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Do 请求任意接口，返回原始响应，非2xx的响应不会返回错误
+// 无法确定接口是否幂等，所以只有GET请求会在失败时重试或切换到其它节点，其它请求只在无法连接时切换
+// 只读的客户端只能发送GET请求
+func (c *Client) Do(ctx context.Context, method, path string, queryParams map[string]string, body []byte) (*rest.Response, error) {
+	if method == string(rest.Get) {
+		return c.get(ctx, path, queryParams)
+	}
+	if c.readOnly && !network.IsDryRun(ctx) {
+		return nil, fmt.Errorf("%w, refusing %s %s", ErrReadOnly, method, path)
+	}
+	start := time.Now()
+	resp, err := c.do(ctx, rest.Method(method), func(baseURL string) (*rest.Response, error) {
+		return c.transport.Send(ctx, rest.Request{
+			Method:      rest.Method(method),
+			BaseURL:     baseURL + path,
+			QueryParams: queryParams,
+			Body:        body,
+			Headers:     c.headers,
+		})
+	})
+	hookErr := err
+	if err == nil && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		hookErr = NewAPIError(method, path, resp.StatusCode, resp.Body)
+	}
+	c.mutated(ctx, method, path, body, resp, hookErr, time.Since(start))
+	return resp, err
+}
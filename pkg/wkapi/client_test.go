@@ -0,0 +1,153 @@
+package wkapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/network"
+)
+
+func TestClientFailoverOnGatewayError(t *testing.T) {
+	newServer := func(code int, hits *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*hits++
+			w.WriteHeader(code)
+			w.Write([]byte(`{"version":"v2"}`))
+		}))
+	}
+	transport := network.NewTransport()
+	transport.Retries = 0
+
+	tests := []struct {
+		name     string
+		codes    []int
+		wantCode int
+		wantHits []int
+	}{
+		{"bad gateway", []int{http.StatusBadGateway, http.StatusOK}, http.StatusOK, []int{1, 1}},
+		{"service unavailable", []int{http.StatusServiceUnavailable, http.StatusOK}, http.StatusOK, []int{1, 1}},
+		{"gateway timeout", []int{http.StatusGatewayTimeout, http.StatusOK}, http.StatusOK, []int{1, 1}},
+		{"other errors returned as is", []int{http.StatusInternalServerError, http.StatusOK}, http.StatusInternalServerError, []int{1, 0}},
+		{"all down returns last response", []int{http.StatusBadGateway, http.StatusServiceUnavailable}, http.StatusServiceUnavailable, []int{1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := make([]int, len(tt.codes))
+			addrs := make([]string, 0, len(tt.codes))
+			for i, code := range tt.codes {
+				server := newServer(code, &hits[i])
+				defer server.Close()
+				addrs = append(addrs, server.URL)
+			}
+			c := New(WithEndpoints(addrs...), WithTransport(transport))
+			resp, err := c.get(context.Background(), "/varz", nil)
+			if err != nil {
+				t.Fatalf("get() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("get() status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			for i := range hits {
+				if hits[i] != tt.wantHits[i] {
+					t.Errorf("hits = %v, want %v", hits, tt.wantHits)
+					break
+				}
+			}
+			// 返回网关错误的节点被标记为不可用，之后排在最后
+			if tt.wantHits[1] == 1 && tt.wantCode == http.StatusOK {
+				if got := c.endpoints.candidates(); got[0] != addrs[1] {
+					t.Errorf("candidates() = %v, want %s first", got, addrs[1])
+				}
+			}
+		})
+	}
+}
+
+// 不能安全重试的请求可能已经在第一个节点上执行，不能在其它节点上重放
+func TestClientDoDoesNotReplayWrites(t *testing.T) {
+	transport := network.NewTransport()
+	transport.Retries = 0
+	transport.Timeout = 50 * time.Millisecond
+
+	// 超时后处理函数仍在执行，需要原子计数
+	var hits [2]atomic.Int32
+	handler := func(i int, code int, delay time.Duration) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			hits[i].Add(1)
+			time.Sleep(delay)
+			w.WriteHeader(code)
+		}
+	}
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		first    http.HandlerFunc // nil表示第一个节点无法连接
+		wantHits [2]int32
+		wantCode int
+		wantErr  bool
+	}{
+		{name: "gateway timeout on write", method: http.MethodPost, first: handler(0, http.StatusGatewayTimeout, 0), wantHits: [2]int32{1, 0}, wantCode: http.StatusGatewayTimeout},
+		{name: "bad gateway on delete", method: http.MethodDelete, first: handler(0, http.StatusBadGateway, 0), wantHits: [2]int32{1, 0}, wantCode: http.StatusBadGateway},
+		{name: "timeout on write", method: http.MethodPut, first: handler(0, http.StatusOK, 200*time.Millisecond), wantHits: [2]int32{1, 0}, wantErr: true},
+		{name: "write to an unreachable node", method: http.MethodPost, wantHits: [2]int32{0, 1}, wantCode: http.StatusOK},
+		{name: "get fails over", method: http.MethodGet, first: handler(0, http.StatusGatewayTimeout, 0), wantHits: [2]int32{1, 1}, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits[0].Store(0)
+			hits[1].Store(0)
+			firstURL := closed.URL
+			if tt.first != nil {
+				first := httptest.NewServer(tt.first)
+				defer first.Close()
+				firstURL = first.URL
+			}
+			second := httptest.NewServer(handler(1, http.StatusOK, 0))
+			defer second.Close()
+
+			c := New(WithEndpoints(firstURL, second.URL), WithTransport(transport))
+			resp, err := c.Do(context.Background(), tt.method, "/channel/subscriber_add", nil, []byte(`{}`))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && resp.StatusCode != tt.wantCode {
+				t.Errorf("Do() status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if got := [2]int32{hits[0].Load(), hits[1].Load()}; got != tt.wantHits {
+				t.Errorf("hits = %v, want %v", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+// 封装的接口都是幂等的，网关错误时切换到下一个节点
+func TestClientPostFailsOver(t *testing.T) {
+	transport := network.NewTransport()
+	transport.Retries = 0
+	var hits [2]int
+	first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[0]++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer first.Close()
+	second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[1]++
+	}))
+	defer second.Close()
+
+	c := New(WithEndpoints(first.URL, second.URL), WithTransport(transport))
+	err := c.SubscriberAdd(context.Background(), &SubscriberAddReq{ChannelId: "g1", ChannelType: 2, Subscribers: []string{"u1"}})
+	if err != nil {
+		t.Fatalf("SubscriberAdd() error = %v", err)
+	}
+	if hits != [2]int{1, 1} {
+		t.Errorf("hits = %v, want [1 1]", hits)
+	}
+}
@@ -0,0 +1,71 @@
+package wkapi
+
+import (
+	"sync"
+	"time"
+)
+
+// 多个服务地址时的选择策略
+const (
+	StrategyFailover   = "failover"    // 按顺序使用，当前地址不可用时切换到下一个
+	StrategyRoundRobin = "round-robin" // 轮询使用所有可用地址
+)
+
+// endpointDownDuration 地址请求失败后被标记为不可用的时长
+const endpointDownDuration = 30 * time.Second
+
+// endpointPool 管理一个上下文的多个http api地址，记录地址的健康状态
+type endpointPool struct {
+	mu        sync.Mutex
+	addrs     []string
+	strategy  string
+	next      int
+	downUntil map[string]time.Time
+}
+
+func newEndpointPool(addrs []string, strategy string) *endpointPool {
+	return &endpointPool{
+		addrs:     addrs,
+		strategy:  strategy,
+		downUntil: make(map[string]time.Time),
+	}
+}
+
+// candidates 本次请求依次尝试的地址，健康的地址排在前面
+func (p *endpointPool) candidates() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.addrs) <= 1 {
+		return p.addrs
+	}
+	start := 0
+	if p.strategy == StrategyRoundRobin {
+		start = p.next % len(p.addrs)
+		p.next++
+	}
+	now := time.Now()
+	healthy := make([]string, 0, len(p.addrs))
+	down := make([]string, 0)
+	for i := 0; i < len(p.addrs); i++ {
+		addr := p.addrs[(start+i)%len(p.addrs)]
+		if now.Before(p.downUntil[addr]) {
+			down = append(down, addr)
+			continue
+		}
+		healthy = append(healthy, addr)
+	}
+	return append(healthy, down...)
+}
+
+func (p *endpointPool) markDown(addr string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.downUntil[addr] = time.Now().Add(endpointDownDuration)
+}
+
+func (p *endpointPool) markUp(addr string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.downUntil, addr)
+}
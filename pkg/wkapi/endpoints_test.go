@@ -0,0 +1,108 @@
+package wkapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEndpointPoolCandidates(t *testing.T) {
+	addrs := []string{"http://a", "http://b", "http://c"}
+	tests := []struct {
+		name     string
+		addrs    []string
+		strategy string
+		down     []string
+		want     [][]string // 依次调用candidates()的结果
+	}{
+		{
+			name:  "single address",
+			addrs: addrs[:1],
+			down:  addrs[:1],
+			want:  [][]string{{"http://a"}, {"http://a"}},
+		},
+		{
+			name:     "failover keeps the order",
+			addrs:    addrs,
+			strategy: StrategyFailover,
+			want:     [][]string{addrs, addrs},
+		},
+		{
+			name:     "default strategy is failover",
+			addrs:    addrs,
+			strategy: "",
+			want:     [][]string{addrs, addrs},
+		},
+		{
+			name:  "failover moves down addresses last",
+			addrs: addrs,
+			down:  []string{"http://a"},
+			want:  [][]string{{"http://b", "http://c", "http://a"}, {"http://b", "http://c", "http://a"}},
+		},
+		{
+			name:  "failover all down keeps the order",
+			addrs: addrs,
+			down:  addrs,
+			want:  [][]string{addrs},
+		},
+		{
+			name:     "round-robin rotates",
+			addrs:    addrs,
+			strategy: StrategyRoundRobin,
+			want: [][]string{
+				{"http://a", "http://b", "http://c"},
+				{"http://b", "http://c", "http://a"},
+				{"http://c", "http://a", "http://b"},
+				{"http://a", "http://b", "http://c"},
+			},
+		},
+		{
+			name:     "round-robin skips down addresses",
+			addrs:    addrs,
+			strategy: StrategyRoundRobin,
+			down:     []string{"http://b"},
+			want: [][]string{
+				{"http://a", "http://c", "http://b"},
+				{"http://c", "http://a", "http://b"},
+				{"http://c", "http://a", "http://b"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newEndpointPool(tt.addrs, tt.strategy)
+			for _, addr := range tt.down {
+				p.markDown(addr)
+			}
+			for i, want := range tt.want {
+				if got := p.candidates(); !reflect.DeepEqual(got, want) {
+					t.Errorf("candidates() #%d = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEndpointPoolMarkUp(t *testing.T) {
+	addrs := []string{"http://a", "http://b"}
+	p := newEndpointPool(addrs, StrategyFailover)
+	p.markDown("http://a")
+	if got := p.candidates(); got[0] != "http://b" {
+		t.Fatalf("candidates() = %v, want http://b first", got)
+	}
+	p.markUp("http://a")
+	if got := p.candidates(); !reflect.DeepEqual(got, addrs) {
+		t.Errorf("candidates() after markUp = %v, want %v", got, addrs)
+	}
+}
+
+func TestEndpointPoolDownExpires(t *testing.T) {
+	addrs := []string{"http://a", "http://b"}
+	p := newEndpointPool(addrs, StrategyFailover)
+	p.markDown("http://a")
+	// 超过不可用时长后恢复原来的顺序
+	p.downUntil["http://a"] = time.Now().Add(-time.Second)
+	if got := p.candidates(); !reflect.DeepEqual(got, addrs) {
+		t.Errorf("candidates() after the down duration = %v, want %v", got, addrs)
+	}
+}
@@ -0,0 +1,79 @@
+package wkapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/WuKongIM/WuKongIMCli/pkg/wkutil"
+)
+
+// ErrUnauthorized 服务端拒绝了请求携带的token，可以通过errors.Is判断APIError
+var ErrUnauthorized = errors.New("token rejected by server")
+
+// ErrNotFound 服务端没有该接口（通常是服务端版本不支持），可以通过errors.Is判断APIError
+var ErrNotFound = errors.New("endpoint not found on server")
+
+// ErrReadOnly 只读的客户端拒绝发送修改数据的请求
+var ErrReadOnly = errors.New("client is read-only")
+
+// maxErrorBodyLen 错误信息中最多显示的响应内容长度
+const maxErrorBodyLen = 200
+
+// APIError 服务端API返回的非200响应
+type APIError struct {
+	Method     string // 请求方法
+	Path       string // 请求路径
+	StatusCode int    // http状态码
+	Msg        string // 服务端返回的msg
+	Body       string // 原始响应内容
+}
+
+// NewAPIError 根据服务端的响应创建APIError
+func NewAPIError(method, path string, statusCode int, body string) *APIError {
+	e := &APIError{
+		Method:     method,
+		Path:       path,
+		StatusCode: statusCode,
+		Body:       body,
+	}
+	if resultMap, err := wkutil.JSONToMap(body); err == nil {
+		if msg, ok := resultMap["msg"].(string); ok {
+			e.Msg = msg
+		}
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	status := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	prefix := fmt.Sprintf("%s %s: %s", e.Method, e.Path, strings.TrimSpace(status))
+	switch {
+	case e.Msg != "":
+		return fmt.Sprintf("%s: %s", prefix, e.Msg)
+	case e.StatusCode == http.StatusUnauthorized:
+		return fmt.Sprintf("%s: %s, check the token", prefix, ErrUnauthorized)
+	case e.StatusCode == http.StatusNotFound:
+		return fmt.Sprintf("%s: %s, the server version may not support it", prefix, ErrNotFound)
+	}
+	body := strings.TrimSpace(e.Body)
+	if body == "" {
+		return prefix
+	}
+	if len(body) > maxErrorBodyLen {
+		body = body[:maxErrorBodyLen] + "..."
+	}
+	return fmt.Sprintf("%s: %s", prefix, body)
+}
+
+// Is 支持 errors.Is(err, ErrUnauthorized) 和 errors.Is(err, ErrNotFound)
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
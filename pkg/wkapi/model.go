@@ -0,0 +1,133 @@
+package wkapi
+
+import (
+	"time"
+
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+)
+
+type userAddrResp struct {
+	TCPAddr string   `json:"tcp_addr"`
+	WSAddr  string   `json:"ws_addr"`
+	UIDs    []string `json:"uids"`
+}
+
+type Varz struct {
+	ServerID    string  `json:"server_id"`   // 服务端ID
+	ServerName  string  `json:"server_name"` // 服务端名称
+	Version     string  `json:"version"`     // 服务端版本
+	Connections int     `json:"connections"` // 当前连接数量
+	Uptime      string  `json:"uptime"`      // 上线时间
+	Mem         int64   `json:"mem"`         // 内存
+	CPU         float64 `json:"cpu"`         // cpu
+
+	InMsgs        int64 `json:"in_msgs"`        // 流入消息数量
+	OutMsgs       int64 `json:"out_msgs"`       // 流出消息数量
+	InBytes       int64 `json:"in_bytes"`       // 流入字节数量
+	OutBytes      int64 `json:"out_bytes"`      // 流出字节数量
+	SlowConsumers int64 `json:"slow_consumers"` // 慢客户端数量
+
+	TCPAddr     string `json:"tcp_addr"`     // tcp地址
+	WSAddr      string `json:"ws_addr"`      // wss地址
+	MonitorAddr string `json:"monitor_addr"` // 监控地址
+	MonitorOn   int    `json:"monitor_on"`   // 监控是否开启
+	Commit      string `json:"commit"`       // git commit id
+	CommitDate  string `json:"commit_date"`  // git commit date
+	TreeState   string `json:"tree_state"`   // git tree state
+}
+
+type ChannelInfoReq struct {
+	ChannelId   string `json:"channel_id"`   // 频道ID
+	ChannelType uint8  `json:"channel_type"` // 频道类型
+	Large       int    `json:"large"`        // 是否是超大群
+	Ban         int    `json:"ban"`          // 是否封禁频道（封禁后此频道所有人都将不能发消息，除了系统账号）
+}
+
+// ChannelCreateReq 频道创建请求
+type ChannelCreateReq struct {
+	ChannelInfoReq
+	Subscribers []string `json:"subscribers"` // 订阅者
+}
+
+type SubscriberAddReq struct {
+	ChannelId      string   `json:"channel_id"`      // 频道ID
+	ChannelType    uint8    `json:"channel_type"`    // 频道类型
+	Reset          int      `json:"reset"`           // 是否重置订阅者 （0.不重置 1.重置），选择重置，将删除原来的所有成员
+	TempSubscriber int      `json:"temp_subscriber"` //  是否是临时订阅者 (1. 是 0. 否)
+	Subscribers    []string `json:"subscribers"`     // 订阅者
+}
+
+type SubscriberReq struct {
+	ChannelId   string   `json:"channel_id"`   // 频道ID
+	ChannelType uint8    `json:"channel_type"` // 频道类型
+	Subscribers []string `json:"subscribers"`  // 订阅者
+}
+
+type ChannelUidsReq struct {
+	ChannelId   string   `json:"channel_id"`   // 频道ID
+	ChannelType uint8    `json:"channel_type"` // 频道类型
+	Uids        []string `json:"uids"`         // 用户ID集合
+}
+
+// UpdateTokenReq 更新用户token的请求
+type UpdateTokenReq struct {
+	UID         string              `json:"uid"`          // 用户ID
+	Token       string              `json:"token"`        // 用户token
+	DeviceFlag  wkproto.DeviceFlag  `json:"device_flag"`  // 设备标识 0.app 1.web 2.pc
+	DeviceLevel wkproto.DeviceLevel `json:"device_level"` // 设备等级 0.从设备 1.主设备
+}
+
+// SystemUIDsReq 添加或移除系统账号的请求
+type SystemUIDsReq struct {
+	UIDs []string `json:"uids"` // 用户ID集合
+}
+
+// OnlineStatus 用户一个在线的设备
+type OnlineStatus struct {
+	UID        string             `json:"uid"`         // 用户ID
+	DeviceFlag wkproto.DeviceFlag `json:"device_flag"` // 设备标识 0.app 1.web 2.pc
+	Online     int                `json:"online"`      // 1.在线 0.离线
+}
+
+// DeviceQuitAll DeviceQuitReq.DeviceFlag 为该值时所有设备下线
+const DeviceQuitAll = -1
+
+// DeviceQuitReq 强制设备下线的请求
+type DeviceQuitReq struct {
+	UID        string `json:"uid"`         // 用户ID
+	DeviceFlag int    `json:"device_flag"` // 设备标识 0.app 1.web 2.pc，DeviceQuitAll 表示所有设备
+}
+
+type Connz struct {
+	Connections []*ConnInfo `json:"connections"` // 连接数
+	Now         time.Time   `json:"now"`         // 查询时间
+	Total       int         `json:"total"`       // 总连接数量
+	Offset      int         `json:"offset"`      // 偏移位置
+	Limit       int         `json:"limit"`       // 限制数量
+}
+
+type ConnInfo struct {
+	ID           int64     `json:"id"`            // 连接ID
+	UID          string    `json:"uid"`           // 用户uid
+	IP           string    `json:"ip"`            // 客户端IP
+	Port         int       `json:"port"`          // 客户端端口
+	LastActivity time.Time `json:"last_activity"` // 最后一次活动时间
+	Uptime       string    `json:"uptime"`        // 启动时间
+	Idle         string    `json:"idle"`          // 客户端闲置时间
+	PendingBytes int       `json:"pending_bytes"` // 等待发送的字节数
+	InMsgs       int64     `json:"in_msgs"`       // 流入的消息数
+	OutMsgs      int64     `json:"out_msgs"`      // 流出的消息数量
+	InBytes      int64     `json:"in_bytes"`      // 流入的字节数量
+	OutBytes     int64     `json:"out_bytes"`     // 流出的字节数量
+	Device       string    `json:"device"`        // 设备
+	DeviceID     string    `json:"device_id"`     // 设备ID
+	Version      uint8     `json:"version"`       // 客户端协议版本
+}
+
+// ConnzReq 查询连接信息的请求
+type ConnzReq struct {
+	Offset int    // 偏移位置
+	Limit  int    // 限制数量，0表示使用服务端的默认值
+	Sort   string // 排序方式
+	UID    string // 只查询该用户的连接
+}
@@ -4,7 +4,14 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
 )
 
 // AesEncryptSimple 加密
@@ -121,3 +128,78 @@ func PKCS7UnPadding(origData []byte) []byte {
 	return origData[:(length - unpadding)]
 
 }
+
+// passphrase加密数据的格式: salt(16) + iv(16) + 密文 + hmac(32)
+const (
+	passphraseSaltLen = 16
+	passphraseMacLen  = sha256.Size
+)
+
+// ErrInvalidPassphrase 密码错误或数据被篡改
+var ErrInvalidPassphrase = errors.New("invalid passphrase or corrupted data")
+
+// AesEncryptWithPassphrase 使用密码加密，密钥由scrypt从密码派生，结果为base64字符串
+func AesEncryptWithPassphrase(origData []byte, passphrase string) (string, error) {
+	salt := make([]byte, passphraseSaltLen)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", err
+	}
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", err
+	}
+	encKey, macKey, err := passphraseKeys(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	crypted, err := AesEncryptPkcs7(origData, encKey, iv)
+	if err != nil {
+		return "", err
+	}
+	data := make([]byte, 0, len(salt)+len(iv)+len(crypted)+passphraseMacLen)
+	data = append(data, salt...)
+	data = append(data, iv...)
+	data = append(data, crypted...)
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(data)
+	data = mac.Sum(data)
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// AesDecryptWithPassphrase 解密AesEncryptWithPassphrase加密的数据
+func AesDecryptWithPassphrase(crypted string, passphrase string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(crypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < passphraseSaltLen+aes.BlockSize*2+passphraseMacLen {
+		return nil, ErrInvalidPassphrase
+	}
+	salt := data[:passphraseSaltLen]
+	iv := data[passphraseSaltLen : passphraseSaltLen+aes.BlockSize]
+	body := data[:len(data)-passphraseMacLen]
+	sum := data[len(data)-passphraseMacLen:]
+	encKey, macKey, err := passphraseKeys(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), sum) {
+		return nil, ErrInvalidPassphrase
+	}
+	ciphertext := body[passphraseSaltLen+aes.BlockSize:]
+	if len(ciphertext)%aes.BlockSize != 0 {
+		return nil, ErrInvalidPassphrase
+	}
+	return AesDecryptPkcs7(ciphertext, encKey, iv)
+}
+
+// passphraseKeys 由密码派生出aes-256密钥和hmac密钥
+func passphraseKeys(passphrase string, salt []byte) ([]byte, []byte, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 64)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key[:32], key[32:], nil
+}